@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Create & use paths of a reported pair, normalized
+type pathPair struct {
+	Create, Use string
+}
+
+// Pairs of paths that aren't reported. Files have one "create<TAB>use" pair
+// per line, the same as -pathsonly output. See -allowlist & -learn.
+type Allowlist map[pathPair]bool
+
+// Populated from -allowlist
+var allowed = make(Allowlist)
+
+func reportPaths(r Report) (pathPair, bool) {
+	if r.Kind != KindPair {
+		return pathPair{}, false
+	}
+	return pathPair{r.Create.NormalizedPath(), r.Use.NormalizedPath()}, true
+}
+
+func (a Allowlist) Add(r Report) {
+	if pair, ok := reportPaths(r); ok {
+		a[pair] = true
+	}
+}
+
+func (a Allowlist) Match(r Report) bool {
+	pair, ok := reportPaths(r)
+	return ok && a[pair]
+}
+
+// Add pairs from file. Lines starting with "#" are comments.
+func (a Allowlist) AddFile(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	for n, line := range strings.Split(string(content), "\n") {
+		if len(strings.TrimSpace(line)) == 0 || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		create, use, ok := strings.Cut(line, "\t")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected create<TAB>use paths", file, n+1)
+		}
+		a[pathPair{create, use}] = true
+	}
+	return nil
+}
+
+// Write pairs to file, sorted, for review before use with -allowlist
+func (a Allowlist) WriteFile(file string) error {
+	var lines []string
+	for pair := range a {
+		lines = append(lines, pair.Create+"\t"+pair.Use)
+	}
+	sort.Strings(lines)
+
+	content := "# create<TAB>use paths learned by -learn; review before use with -allowlist\n"
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	return os.WriteFile(file, []byte(content), 0644)
+}
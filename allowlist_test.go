@@ -0,0 +1,49 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// With -learn, findings are only recorded, even if other sinks are set
+func TestLearnIsExclusive(t *testing.T) {
+	setFlag(t, flagFailFast, true)
+	setFlag(t, flagGroupWin, time.Second)
+
+	var emitted []Report
+	out := NewTimeline()
+	out.OnReport(func(r Report) {
+		emitted = append(emitted, r)
+	})
+	learned := make(Allowlist)
+	found := false
+	report, _, closeSinks := reportChain(&out, learned, &found)
+
+	tm := NewTimeline()
+	tm.OnReport(report)
+	ParseLogContent(&tm, []byte(rawLog(createEvent(1, "/tmp/a", "10"), useEvent(2, "/tmp/A", "10"))))
+	closeSinks()
+
+	if len(emitted) != 0 || found {
+		t.Errorf("got %d findings reported (found=%v), want none", len(emitted), found)
+	}
+	if !learned[pathPair{"/tmp/a", "/tmp/A"}] || len(learned) != 1 {
+		t.Errorf("learned %v, want /tmp/a & /tmp/A", learned)
+	}
+}
+
+func TestLearnConflicts(t *testing.T) {
+	if conflicts := learnConflicts(); len(conflicts) != 0 {
+		t.Errorf("conflicts w/o other sinks: %v", conflicts)
+	}
+
+	setFlag(t, flagWebhook, "http://localhost/hook")
+	setFlag(t, flagSQLite, "findings.db")
+	setFlag(t, flagFailFast, true)
+	setFlag(t, flagGroupWin, time.Second)
+	want := []string{"-webhook", "-sqlite", "-failfast", "-groupwindow"}
+	if conflicts := learnConflicts(); !slices.Equal(conflicts, want) {
+		t.Errorf("conflicts = %v, want %v", conflicts, want)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// Placeholder for exe of events without one, ex. of kernel threads
+const UnknownExe = "<unknown>"
+
+// exe, or UnknownExe if it's missing or "(null)"
+func knownExe(exe string) string {
+	if len(exe) == 0 || exe == "(null)" {
+		return UnknownExe
+	}
+	return exe
+}
+
+// Executables running scripts. For them, exe alone doesn't tell which
+// program confused names.
+var interpreters = map[string]bool{
+	"sh":      true,
+	"bash":    true,
+	"dash":    true,
+	"zsh":     true,
+	"ksh":     true,
+	"fish":    true,
+	"python":  true,
+	"python2": true,
+	"python3": true,
+	"perl":    true,
+	"ruby":    true,
+	"node":    true,
+	"php":     true,
+}
+
+// Program doing the syscall as shown in output. For interpreters, the script
+// is added from comm or proctitle, ex. "bash:links.sh" for /bin/bash.
+func (i Inode) Attribution() string {
+	exe := path.Base(knownExe(i.Exe))
+	if !interpreters[exe] {
+		return exe
+	}
+
+	if script := i.script(); len(script) > 0 {
+		return exe + ":" + script
+	}
+	return exe
+}
+
+// Script run by an interpreter. comm is the script's name unless it's run as
+// "bash script.sh", in which case it's taken from proctitle.
+func (i Inode) script() string {
+	exe := path.Base(i.Exe)
+	if comm := i.Syscall.Comm; len(comm) > 0 && comm != exe {
+		return comm
+	}
+
+	args := i.PtArgs
+	if args == nil { /* interpreted logs */
+		args = strings.Fields(i.Proctitle)
+	}
+	for _, arg := range args[min(1, len(args)):] {
+		if !strings.HasPrefix(arg, "-") {
+			return path.Base(arg)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKnownExe(t *testing.T) {
+	tests := map[string]string{
+		"":             UnknownExe,
+		"(null)":       UnknownExe,
+		"/usr/bin/git": "/usr/bin/git",
+	}
+	for exe, want := range tests {
+		if got := knownExe(exe); got != want {
+			t.Errorf("knownExe(%q) = %q, want %q", exe, got, want)
+		}
+	}
+}
+
+func TestEventWithoutExe(t *testing.T) {
+	tests := map[string]string{
+		"missing": "",
+		"null":    " exe=(null)",
+	}
+	for name, exe := range tests {
+		use := strings.Replace(useEvent(2, "/tmp/A", "10"), ` exe="/usr/bin/user"`, exe, 1)
+		reports, _ := reportsOf(ParseLogContent, rawLog(createEvent(1, "/tmp/a", "10"), use))
+		if len(reports) != 1 {
+			t.Fatalf("%s: got %d reports, want 1", name, len(reports))
+		}
+
+		u := reports[0].Use
+		if got := u.Attribution(); got != UnknownExe {
+			t.Errorf("%s: attributed to %q, want %q", name, got, UnknownExe)
+		}
+		if s := u.String(); !strings.Contains(s, UnknownExe) || strings.Contains(s, "(null)") {
+			t.Errorf("%s: use printed as %s, want %s in place of exe", name, s, UnknownExe)
+		}
+	}
+}
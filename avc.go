@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// A decision of SELinux or AppArmor from an AVC record
+type AVC struct {
+	Msg      string
+	Verdict  string // ex. denied or granted; DENIED or ALLOWED for AppArmor
+	Perms    string `json:",omitempty"` // ex. "write" or "read write"
+	Pid      string `json:",omitempty"`
+	Comm     string `json:",omitempty"`
+	Name     string `json:",omitempty"` // base name (SELinux) or path (AppArmor)
+	Ino      string `json:",omitempty"` // SELinux only
+	Tcontext string `json:",omitempty"` // SELinux context of target
+	Tclass   string `json:",omitempty"` // ex. file or dir
+	Profile  string `json:",omitempty"` // AppArmor profile
+}
+
+// SELinux prefix of AVC records, ex. "avc:  denied  { write } for  pid=..."
+var avcPrefix = regexp.MustCompile(`^\s*avc:\s+(\S+)\s+\{([^}]*)\}\s+for\s+`)
+
+// Move the SELinux prefix of an AVC record body, which isn't key=value, to
+// avc= & perms=. AppArmor bodies are only key=value.
+func splitAVCPrefix(body string) (string, map[string]string) {
+	m := avcPrefix.FindStringSubmatch(body)
+	if m == nil {
+		return body, nil
+	}
+	return body[len(m[0]):], map[string]string{
+		"avc":   m[1],
+		"perms": strings.TrimSpace(m[2]),
+	}
+}
+
+func NewAVC(r Record) AVC {
+	unquote := func(k string) string { return strings.Trim(r.Body[k], "\"") }
+
+	a := AVC{
+		Msg:      r.Msg,
+		Verdict:  unquote("avc"),
+		Perms:    unquote("perms"),
+		Pid:      r.Body["pid"],
+		Comm:     unquote("comm"),
+		Name:     unquote("name"),
+		Ino:      r.Body["ino"],
+		Tcontext: r.Body["tcontext"],
+		Tclass:   r.Body["tclass"],
+		Profile:  unquote("profile"),
+	}
+	if apparmor, ok := r.Body["apparmor"]; ok {
+		a.Verdict = strings.Trim(apparmor, "\"")
+		a.Perms = unquote("requested_mask")
+	}
+	if !r.Interpreted && len(a.Comm) > 0 {
+		a.Comm = r.decodeStr(r.Body["comm"])
+	}
+	return a
+}
+
+// AVC records of an event
+func (rs Records) AVCs() []AVC {
+	var avcs []AVC
+	for _, r := range rs.Records {
+		if r.Type == "AVC" {
+			avcs = append(avcs, NewAVC(r))
+		}
+	}
+	return avcs
+}
+
+func (a AVC) String() string {
+	s := fmt.Sprintf("%s { %s } name=%s", a.Verdict, a.Perms, a.Name)
+	if len(a.Ino) > 0 {
+		s += " ino=" + a.Ino
+	}
+	if len(a.Profile) > 0 {
+		s += " profile=" + a.Profile
+	}
+	return s + fmt.Sprintf(" comm=%s pid=%s", a.Comm, a.Pid)
+}
+
+// Does the AVC refer to the inode? SELinux logs the inode# & base name,
+// AppArmor the full path.
+func (a AVC) About(i *Inode) bool {
+	switch {
+	case len(a.Ino) > 0:
+		return a.Ino == i.InodeNum
+	case strings.HasPrefix(a.Name, "/"):
+		return a.Name == i.NormalizedPath()
+	}
+	return len(a.Name) > 0 && a.Name == path.Base(i.NormalizedPath())
+}
+
+// AVCs kept for correlation, and how far from a use they may be
+const (
+	maxAVCs   = 256
+	avcWindow = 5 * time.Second
+)
+
+// Remember AVCs of an event for annotating reports. See AVCsAbout.
+func (tm *Timeline) trackAVCs(rs *Records) {
+	for _, a := range rs.AVCs() {
+		tm.avcs = append(tm.avcs, a)
+	}
+	if len(tm.avcs) > maxAVCs {
+		tm.avcs = tm.avcs[len(tm.avcs)-maxAVCs:]
+	}
+}
+
+// AVCs about the create or use of a report, from around the time of the use.
+// Reports are made as the use is applied, so later AVCs aren't included.
+func (tm *Timeline) AVCsAbout(r Report) []AVC {
+	if len(tm.avcs) == 0 {
+		return nil
+	}
+	when, ok := r.Use.Time()
+
+	var found []AVC
+	for _, a := range tm.avcs {
+		if !a.About(r.Create) && !a.About(r.Use) {
+			continue
+		}
+		if t, ok2 := parseMsgTime(a.Msg); ok && ok2 {
+			if d := t.Sub(when); d > avcWindow || d < -avcWindow {
+				continue
+			}
+		}
+		found = append(found, a)
+	}
+	return found
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// Serial & time of an applied event
+type eventPos struct {
+	serial uint64
+	time   time.Time
+}
+
+// Detect a reboot before the event: serials restart at boot, so a lower
+// serial at a later time is a new boot. Concatenated logs of several boots
+// would otherwise correlate unrelated inodes & fds, since pids and dynamic
+// devices (ex. 00:39 of tmpfs) are reused. All state is reset at a reboot.
+func (tm *Timeline) detectBoot(rs *Records) {
+	if len(rs.Records) == 0 {
+		return
+	}
+
+	msg := rs.Records[0].Msg
+	serial, err := strconv.ParseUint(parseMsgSerial(msg), 10, 64)
+	t, ok := parseMsgTime(msg)
+	if err != nil || !ok {
+		return
+	}
+
+	last := tm.last
+	tm.last = eventPos{serial, t}
+	if last.serial == 0 || serial >= last.serial || t.Before(last.time) {
+		return // same boot, or logs aren't in order
+	}
+
+	tm.Boot++
+	if *flagVerbose {
+		log.Printf("serial restarted at %s, assuming boot #%d", msg, tm.Boot)
+	}
+	tm.history = make(map[string]Inode)
+	tm.used = make(map[string]bool)
+	tm.fds = make(FdTable)
+	tm.links = make(LinkTable)
+	tm.checks = make(CheckTable)
+	tm.procs = make(ProcTree)
+	tm.dirs = make(DirTable)
+	tm.chains = make(ChainTable)
+	tm.avcs = nil
+	tm.order = newHistoryOrder()
+	tm.chkOrder = newHistoryOrder()
+}
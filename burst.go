@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Findings coalesced by a Coalescer so far
+type burst struct {
+	r    Report
+	last time.Time // of the latest finding
+}
+
+// Coalesces findings of the same category, paths & exes within -groupwindow
+// of each other into one, with the count & the time of the last one. A burst
+// is passed on once an event (see Tick) or finding comes after the window, or
+// on Close.
+type Coalescer struct {
+	window time.Duration
+	next   func(Report)
+	bursts map[string]*burst
+	order  []string // keys of bursts, in order of their first finding
+}
+
+func NewCoalescer(window time.Duration, next func(Report)) *Coalescer {
+	return &Coalescer{window: window, next: next, bursts: make(map[string]*burst)}
+}
+
+func burstKey(r Report) string {
+	if r.Kind == KindConfig {
+		return fmt.Sprint(r.Category, "|", r.Host, "|", r.Config)
+	}
+	return fmt.Sprint(r.Category, "|", r.Host, "|", r.Create.NormalizedPath(), "|", r.Use.NormalizedPath(),
+		"|", r.Create.Syscall.Exe, "|", r.Use.Syscall.Exe)
+}
+
+func (c *Coalescer) Send(r Report) {
+	at, ok := r.Use.Time()
+	if !ok {
+		c.next(r)
+		return
+	}
+	c.expire(at)
+
+	key := burstKey(r)
+	if b, ok := c.bursts[key]; ok {
+		b.r.Count++
+		b.r.Until = at.UTC().Format(time.RFC3339Nano) // -tz is applied in text output
+		b.last = at
+		return
+	}
+	c.bursts[key] = &burst{r: r, last: at}
+	c.order = append(c.order, key)
+}
+
+// Pass on bursts that ended before an event at now, even if it has no
+// findings, so bursts aren't held while there are none, ex. with -replay
+func (c *Coalescer) Tick(now time.Time) {
+	c.expire(now)
+}
+
+// Pass on bursts whose last finding is more than the window before now
+func (c *Coalescer) expire(now time.Time) {
+	kept := c.order[:0]
+	for _, key := range c.order {
+		b := c.bursts[key]
+		if now.Sub(b.last) > c.window {
+			c.pass(b)
+			delete(c.bursts, key)
+			continue
+		}
+		kept = append(kept, key)
+	}
+	c.order = kept
+}
+
+func (c *Coalescer) pass(b *burst) {
+	if b.r.Count > 0 {
+		b.r.Count++ /* the first finding */
+	}
+	c.next(b.r)
+}
+
+// Pass on all bursts
+func (c *Coalescer) Close() {
+	for _, key := range c.order {
+		c.pass(c.bursts[key])
+	}
+	c.bursts = make(map[string]*burst)
+	c.order = nil
+}
+
+// End of a burst in -tz, for text output
+func untilLabel(until string) string {
+	t, err := time.Parse(time.RFC3339Nano, until)
+	if err != nil {
+		return until
+	}
+	return t.In(outputLocation).Format(timeLabelLayout)
+}
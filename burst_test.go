@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Finding of /tmp/a used as /tmp/A, sec seconds into the log
+func finding(sec int) Report {
+	msg := fmt.Sprintf("audit(%d.000:%d)", 1626882755+sec, sec+1)
+	return Report{
+		Category: CategoryCaseMismatch,
+		Create:   &Inode{Msg: msg, Path: "/tmp/a"},
+		Use:      &Inode{Msg: msg, Path: "/tmp/A"},
+	}
+}
+
+func TestCoalescer(t *testing.T) {
+	var passed []Report
+	c := NewCoalescer(time.Second, func(r Report) {
+		passed = append(passed, r)
+	})
+
+	c.Send(finding(0))
+	c.Send(finding(1))
+	c.Tick(time.Unix(1626882755+1, 0)) // within the window
+	if len(passed) != 0 {
+		t.Fatalf("burst passed on within the window")
+	}
+
+	c.Tick(time.Unix(1626882755+5, 0)) // an event w/o findings
+	if len(passed) != 1 {
+		t.Fatalf("got %d bursts after the window, want 1", len(passed))
+	}
+	if r := passed[0]; r.Count != 2 || r.Until != "2021-07-21T15:52:36Z" {
+		t.Errorf("got burst of %d until %q, want 2 until 2021-07-21T15:52:36Z", r.Count, r.Until)
+	}
+}
+
+// -failfast reports the first finding as it's found, even with -groupwindow
+func TestFailFastNotCoalesced(t *testing.T) {
+	setFlag(t, flagFailFast, true)
+	setFlag(t, flagGroupWin, time.Minute)
+	setFlag(t, &progress, NewProgress("", 0))
+
+	var emitted []Report
+	out := NewTimeline()
+	out.OnReport(func(r Report) {
+		emitted = append(emitted, r)
+	})
+	found := false
+	report, _, closeSinks := reportChain(&out, nil, &found)
+	defer closeSinks()
+
+	report(finding(0))
+	report(finding(1))
+	if !found || len(emitted) != 1 || emitted[0].Count != 0 {
+		t.Errorf("got %d findings (found=%v), want the first as is", len(emitted), found)
+	}
+}
+
+func TestUntilLabel(t *testing.T) {
+	setFlag(t, &outputLocation, time.FixedZone("UTC+2", 2*60*60))
+	if got := untilLabel("2021-07-21T15:52:36.5Z"); got != "2021-07-21T17:52:36.500+02:00" {
+		t.Errorf("untilLabel = %q", got)
+	}
+}
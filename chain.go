@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+// Operations kept per inode for -chain; older ones are dropped
+const maxChain = 32
+
+// Operations applied to each inode in event order, keyed by inode name. See
+// -chain.
+type ChainTable map[string][]Inode
+
+// Remember an operation on an inode
+func (c ChainTable) Add(i *Inode) {
+	name := i.Name()
+	ops := append(c[name], *i)
+	if len(ops) > maxChain {
+		ops = ops[len(ops)-maxChain:]
+	}
+	c[name] = ops
+}
+
+// Operations on the inode of a report up to its use, ex. create, rename
+// (DELETE & CREATE) & the confused use
+func (c ChainTable) Of(r Report) []Inode {
+	ops := c[r.Create.Name()]
+	return append([]Inode(nil), ops...)
+}
+
+// Print chain of a report, one operation per line
+func printChain(chain []Inode) {
+	fmt.Printf("\tchain:\n")
+	for n, i := range chain {
+		fmt.Printf("\t  %d. %s%v\n", n+1, i.Operation, &i)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Verify prerequisites of the tool, for "check". Prints a line per check &
+// returns whether all passed.
+func HealthCheck() bool {
+	pass := true
+	result := func(ok bool, what, detail string) {
+		status := "ok  "
+		if !ok {
+			status = "FAIL"
+			pass = false
+		}
+		fmt.Printf("%s %s: %s\n", status, what, detail)
+	}
+
+	if _, err := exec.Command("ausyscall", "--dump").Output(); err != nil {
+		result(false, "ausyscall", fmt.Sprintf("%v; syscall names come from the cache or built-in x86_64 table", err))
+	} else {
+		result(true, "ausyscall", "dumps syscall names")
+	}
+
+	if _, cached := readSyscallCache(); cached {
+		file, _ := syscallCacheFile()
+		result(true, "syscall cache", file)
+	} else {
+		result(true, "syscall cache", "none yet, made by the first run with ausyscall")
+	}
+
+	result(len(AuSyscalls) > 0, "syscall table", fmt.Sprintf("%d syscall names loaded", len(AuSyscalls)))
+
+	for _, f := range flagLogfiles {
+		ok, detail := checkLogFile(f.Path)
+		result(ok, "input "+f.Path, detail)
+	}
+	for _, f := range flagTars {
+		err := ReadTarLogs(f.Path, func(string, []byte) {})
+		result(err == nil, "input "+f.Path, fmt.Sprint("tar archive; ", errOrOK(err)))
+	}
+	return pass
+}
+
+func errOrOK(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "readable"
+}
+
+// Is the file readable & in a supported format?
+func checkLogFile(file string) (bool, string) {
+	content, err := ReadLogFile(file)
+	if err != nil {
+		return false, err.Error()
+	}
+	if *flagInFormat != "raw" {
+		return true, fmt.Sprintf("readable, %d bytes of %s input", len(content), *flagInFormat)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r") // CRLF line endings
+		if ignoredLine(line) || line == AuditdSep || strings.HasPrefix(line, "time->") {
+			continue
+		}
+		format := DetectFormat(line)
+		if format == FormatUnsupported {
+			return false, fmt.Sprintf("unsupported log format at line: %q", line)
+		}
+		return true, fmt.Sprintf("readable, %s auditd log", format)
+	}
+	return true, "readable, but empty"
+}
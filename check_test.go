@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckLogFileWithComments(t *testing.T) {
+	log := "# notes on this log\n\n   \n" + rawLog(createEvent(1, "/tmp/a", "10"))
+	tests := map[string]string{
+		"LF":   log,
+		"CRLF": strings.ReplaceAll(log, "\n", "\r\n"),
+	}
+	for name, content := range tests {
+		file := filepath.Join(t.TempDir(), "audit.log")
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if ok, status := checkLogFile(file); !ok {
+			t.Errorf("%s: check failed: %s", name, status)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+// Syscalls checking a path without writing to it (x86_64)
+var checkSyscalls = map[string]uint64{
+	"stat":       4,
+	"lstat":      6,
+	"access":     21,
+	"newfstatat": 262,
+	"faccessat":  269,
+	"statx":      332,
+	"faccessat2": 439,
+}
+
+// Syscalls writing to a path without opening it (x86_64). ftruncate takes an
+// fd instead, which -trackfds follows.
+var pathWriteSyscalls = map[string]uint64{
+	"truncate": 76,
+}
+
+// Access mode bits of open flags, and flags changing what an open does
+const (
+	O_ACCMODE = 0x3
+	O_RDONLY  = 0x0
+	O_TRUNC   = 0x200
+	O_PATH    = 0x200000 // fd only locates the file, contents aren't accessed
+)
+
+// Flags of an open syscall. openat2 takes them in a struct, so they aren't
+// logged. Flags that look like pointers aren't returned.
+func (s Syscall) OpenFlags() (uint64, bool) {
+	switch {
+	case s.Name == "open" || (len(s.Name) == 0 && s.Number == 2):
+		return s.A1, s.plausibleFlags(s.A1)
+	case s.Name == "creat" || (len(s.Name) == 0 && s.Number == 85):
+		return 0x241, true /* O_CREAT|O_WRONLY|O_TRUNC */
+	case s.Name == "openat" || (len(s.Name) == 0 && s.Number == 257):
+		return s.A2, s.plausibleFlags(s.A2)
+	}
+	return 0, false
+}
+
+// Paths last checked, keyed by their absolute path. See -writeaftercheck.
+// Checks are evicted like creates, see -historymax & -evict, but outlive
+// DELETEs of the checked inode, as a swap is a delete & re-create between
+// the check & the write.
+type CheckTable map[string]Inode
+
+// Remember paths checked in an event, and report if a later write-capable
+// open or truncate of a checked path reaches another inode than the check
+// did, i.e. the file was swapped between the check and the write.
+func (tm *Timeline) trackChecks(rs *Records) {
+	s, ok := rs.Syscall()
+	if !ok || !s.Success {
+		return
+	}
+
+	check := syscallIn(checkSyscalls, s)
+	write := syscallIn(pathWriteSyscalls, s)
+	if flags, ok := s.OpenFlags(); ok {
+		check = flags&O_ACCMODE == O_RDONLY && flags&O_TRUNC == 0
+		write = !check
+	}
+	if !check && !write {
+		return
+	}
+
+	for i := range rs.InodeSeq() {
+		if i.Operation == "PARENT" || i.Name() == NoName {
+			continue
+		}
+		path := i.NormalizedPath()
+		if check {
+			tm.checks[path] = i
+			if evicting() {
+				tm.chkOrder.touch(path, &i)
+				now, ok := i.Time()
+				tm.chkOrder.evict(tm.checks, now, ok, func(path string) {
+					delete(tm.checks, path)
+				})
+			}
+			continue
+		}
+		checked, ok := tm.checks[path]
+		if !ok {
+			continue
+		}
+		delete(tm.checks, path)
+		delete(tm.chkOrder.born, path)
+		if checked.Name() != i.Name() {
+			tm.Report(NewReport(CategoryWriteAfterCheck, &checked, &i))
+		}
+	}
+}
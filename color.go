@@ -0,0 +1,28 @@
+package main
+
+import "os"
+
+// ANSI colors of labels in text output
+const (
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// Set by setupColor
+var colorEnabled bool
+
+// Color labels only for text output to a terminal, unless disabled by
+// -nocolor or NO_COLOR (see https://no-color.org)
+func setupColor() {
+	noColor := len(os.Getenv("NO_COLOR")) > 0
+	machineReadable := *flagJson || len(*flagFields) > 0 || *flagPathsOnly || *flagGHAnnot
+	colorEnabled = !*flagNoColor && !noColor && !machineReadable && isTerminal(os.Stdout)
+}
+
+func paint(color, s string) string {
+	if !colorEnabled {
+		return s
+	}
+	return color + s + colorReset
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Decides whether the create & use paths of an inode are the same name
+type Comparator func(create, use string) bool
+
+// Comparators selectable via -compare
+var comparators = map[string]Comparator{
+	// byte-wise equality
+	"strict": func(create, use string) bool {
+		return create == use
+	},
+	// ignore differences in case (simple Unicode case folding)
+	"icase": func(create, use string) bool {
+		return strings.EqualFold(create, use)
+	},
+	// resolve symlinks on the running system; unresolvable paths are
+	// compared as-is
+	"symlink": func(create, use string) bool {
+		return resolveSymlinks(create) == resolveSymlinks(use)
+	},
+}
+
+func resolveSymlinks(p string) string {
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return p
+	}
+	return resolved
+}
+
+// Names of available comparators
+func comparatorNames() []string {
+	var names []string
+	for name := range comparators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CONFIG_CHANGE ops removing audit rules; older kernels log del_rule
+var ruleRemovalOps = map[string]bool{
+	"remove_rule": true,
+	"del_rule":    true,
+}
+
+// Describe a CONFIG_CHANGE record that blinds the detector, i.e. one that
+// removes an audit rule (op=remove_rule or op=del_rule) or disables auditing
+// (audit_enabled=0, ex. by auditctl -e 0). Failed changes are ignored.
+func blindingChange(r Record) (string, bool) {
+	if res := r.Body["res"]; res == "0" || res == "no" {
+		return "", false
+	}
+
+	var why string
+	op := strings.Trim(r.Body["op"], "\"")
+	switch {
+	case ruleRemovalOps[op]:
+		why = fmt.Sprintf("audit rule removed (op=%s key=%s list=%s)",
+			op, r.Body["key"], r.Body["list"])
+	case r.Body["audit_enabled"] == "0":
+		why = "auditing disabled (audit_enabled=0)"
+	default:
+		return "", false
+	}
+
+	if auid, ok := r.Body["auid"]; ok {
+		why += " by auid=" + auid
+	}
+	return why, true
+}
+
+// Report changes to the audit configuration that blind the detector. See
+// -watchrules.
+func (tm *Timeline) watchRules(rs *Records) {
+	for _, r := range rs.Records {
+		if r.Type != "CONFIG_CHANGE" {
+			continue
+		}
+		why, ok := blindingChange(r)
+		if !ok {
+			continue
+		}
+
+		change := &Inode{
+			Timestamp: r.Timestamp,
+			Msg:       r.Msg,
+			Operation: r.Type,
+			Exe:       strings.Trim(r.Body["exe"], "\""),
+		}
+		tm.Report(Report{Category: CategoryAuditBlinded, Kind: KindConfig, Use: change, Config: why})
+	}
+}
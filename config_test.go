@@ -0,0 +1,38 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+const ruleRemoved = `type=CONFIG_CHANGE msg=audit(1626882755.003:3): auid=1000 ses=1 op=remove_rule key="watch" list=4 res=1`
+
+// Config changes are reported as such, even with filters meant for pairs
+func TestConfigChangeReport(t *testing.T) {
+	setFlag(t, flagWatchRules, true)
+	setFlag(t, flagPrivTrans, true)
+	setFlag(t, &pathInclude, regexp.MustCompile("^/tmp/"))
+
+	reports, _ := reportsOf(ParseLogContent, rawLog(ruleRemoved))
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	r := reports[0]
+	if r.Kind != KindConfig || r.Category != CategoryAuditBlinded || r.Create != nil {
+		t.Errorf("got %s report of %q, want a config change", r.Category, r.Kind)
+	}
+	if r.Severity() != SeverityHigh {
+		t.Errorf("severity %s, want high", r.Severity())
+	}
+	for name, get := range reportFields {
+		if name == "create_path" && get(r) != "" {
+			t.Errorf("create_path = %q, want empty", get(r))
+		}
+	}
+	if _, ok := reportPaths(r); ok {
+		t.Errorf("config change has a create-use path pair")
+	}
+	if counts := countPaths(reports); len(counts) != 0 {
+		t.Errorf("config change counted as %d paths", len(counts))
+	}
+}
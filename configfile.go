@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config file read when -config isn't given; skipped if it doesn't exist
+const defaultConfig = ".ncmonitor.yaml"
+
+// Set flags that weren't given on the command line from a config file of
+// "flag: value" lines, ex. "json: true". Lists (ex. for file) are given as
+// "[a, b]" or as "- a" lines below the key. Returns the flags set.
+//
+// Only this subset of YAML is supported.
+func flagsFromConfig(given map[string]bool) (map[string]bool, error) {
+	file := *flagConfig
+	if len(file) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		file = filepath.Join(home, defaultConfig)
+		if _, err := os.Stat(file); err != nil {
+			return nil, nil
+		}
+	}
+
+	values, err := readConfig(file)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	for _, kv := range values {
+		if flag.Lookup(kv[0]) == nil {
+			return nil, fmt.Errorf("%s: unknown flag %q", file, kv[0])
+		}
+		if given[kv[0]] {
+			continue
+		}
+		if err := flag.Set(kv[0], kv[1]); err != nil {
+			return nil, fmt.Errorf("%s: invalid %s: %v", file, kv[0], err)
+		}
+		set[kv[0]] = true
+	}
+	return set, nil
+}
+
+// Key-value pairs of the config in order, with one pair per list item
+func readConfig(file string) ([][2]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values [][2]string
+	var key string // of the list being read
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := stripComment(scanner.Text())
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case len(trimmed) == 0 || trimmed == "---":
+			continue
+		case strings.HasPrefix(trimmed, "- "):
+			if len(key) == 0 {
+				return nil, fmt.Errorf("%s:%d: list item without a key", file, n)
+			}
+			values = append(values, [2]string{key, unquote(trimmed[2:])})
+			continue
+		}
+
+		k, v, ok := strings.Cut(trimmed, ":")
+		if !ok || line != strings.TrimLeft(line, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected \"flag: value\", got %q", file, n, trimmed)
+		}
+		key = strings.TrimPrefix(strings.TrimSpace(k), "-")
+		v = strings.TrimSpace(v)
+
+		switch {
+		case len(v) == 0: /* list follows */
+		case strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]"):
+			for _, item := range strings.Split(v[1:len(v)-1], ",") {
+				if item = strings.TrimSpace(item); len(item) > 0 {
+					values = append(values, [2]string{key, unquote(item)})
+				}
+			}
+		default:
+			values = append(values, [2]string{key, yamlBool(unquote(v))})
+		}
+	}
+	return values, scanner.Err()
+}
+
+// Drop a "#" comment, unless it's quoted
+func stripComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquote(v string) string {
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		if s, err := strconv.Unquote(v); err == nil {
+			return s
+		}
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+	}
+	return v
+}
+
+// YAML spellings of booleans accepted by flag.Set
+func yamlBool(v string) string {
+	switch strings.ToLower(v) {
+	case "yes", "on":
+		return "true"
+	case "no", "off":
+		return "false"
+	}
+	return v
+}
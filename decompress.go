@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Reads compressed logs of a given format
+type Decompressor struct {
+	Name      string
+	Ext       string // ex. ".gz"
+	Magic     []byte // leading bytes of compressed files
+	NewReader func(io.Reader) (io.Reader, error)
+}
+
+// Available decompressors; only gzip is built-in to keep the tool free of
+// dependencies. Others (ex. zstd, xz) can be added with RegisterDecompressor,
+// for example from an init() in a file behind a build tag.
+var decompressors []Decompressor
+
+func RegisterDecompressor(d Decompressor) {
+	decompressors = append(decompressors, d)
+}
+
+func init() {
+	RegisterDecompressor(Decompressor{
+		Name:  "gzip",
+		Ext:   ".gz",
+		Magic: []byte{0x1f, 0x8b},
+		NewReader: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+	})
+}
+
+// Find decompressor by magic bytes, or else by file extension
+func findDecompressor(file string, header []byte) *Decompressor {
+	for i, d := range decompressors {
+		if len(d.Magic) > 0 && bytes.HasPrefix(header, d.Magic) {
+			return &decompressors[i]
+		}
+	}
+	for i, d := range decompressors {
+		if len(d.Ext) > 0 && strings.HasSuffix(file, d.Ext) {
+			return &decompressors[i]
+		}
+	}
+	return nil
+}
+
+// Read log file, decompressing it if needed
+func ReadLogFile(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := decompress(file, f)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// Reader of a possibly compressed file's content
+func decompress(file string, r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(16) // shorter for small files
+
+	if d := findDecompressor(file, header); d != nil {
+		return d.NewReader(br)
+	}
+	return br, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// A kind of confusion the tool reports. See -listrules.
+type Detection struct {
+	ID       Category `json:"id"`
+	Name     string   `json:"name"`
+	Flag     string   `json:"flag,omitempty"` // enables it; always on if empty
+	Severity string   `json:"severity"`       // or "by-pair", see Report.Severity
+	Enabled  bool     `json:"enabled"`
+}
+
+// Every built-in category of reports, with the flag enabling it. Categories
+// of rules are also listed via CategoryLister, see listDetections.
+var categories = []Detection{
+	{ID: CategoryPathMismatch, Name: "use reached inode by another name"},
+	{ID: CategoryCaseMismatch, Name: "names differ only in case"},
+	{ID: CategoryRecreate, Name: "created again under another name before use", Flag: "recreate"},
+	{ID: CategoryFdMismatch, Name: "fd used for another inode than it was opened for", Flag: "trackfds"},
+	{ID: CategoryProcFdMismatch, Name: "fd re-opened via /proc/*/fd reached another inode", Flag: "trackfds"},
+	{ID: CategoryAuditBlinded, Name: "audit rule removed or auditing disabled", Flag: "watchrules", Severity: SeverityHigh.String()},
+	{ID: CategoryContextMismatch, Name: "SELinux object context changed", Flag: "selinux"},
+	{ID: CategoryWriteAfterCheck, Name: "path reached another inode when written than when checked", Flag: "writeaftercheck"},
+	{ID: CategoryParentMismatch, Name: "use resolved beneath another inode than the directory created at its parent's path", Flag: "parentdirs"},
+}
+
+// Implemented by rules listing the categories they report in -listrules.
+// Rules not implementing it, or reporting categories missing in categories,
+// are listed by their type.
+type CategoryLister interface {
+	Categories() []Category
+}
+
+// Detections of categories and of rules added to tm, with whether the given
+// flags enable them
+func listDetections(tm *Timeline) []Detection {
+	var list []Detection
+	listed := make(map[Category]int)
+	for _, d := range categories {
+		if len(d.Severity) == 0 {
+			d.Severity = "by-pair"
+		}
+		d.Enabled = len(d.Flag) == 0
+		if f := flag.Lookup(d.Flag); f != nil {
+			d.Enabled = f.Value.String() == "true"
+		}
+		listed[d.ID] = len(list)
+		list = append(list, d)
+	}
+
+	for _, rule := range tm.rules {
+		reported := []Category{CategoryNone}
+		if l, ok := rule.(CategoryLister); ok {
+			reported = l.Categories()
+		}
+		for _, category := range reported {
+			if n, ok := listed[category]; ok && category != CategoryNone {
+				list[n].Enabled = true
+				continue
+			}
+			list = append(list, Detection{
+				ID:       category,
+				Name:     fmt.Sprintf("reported by rule %T", rule),
+				Severity: "by-pair",
+				Enabled:  true,
+			})
+			if category != CategoryNone {
+				listed[category] = len(list) - 1
+			}
+		}
+	}
+	return list
+}
+
+// Print detections as a table, or as json with -json
+func printDetections(tm *Timeline) {
+	list := listDetections(tm)
+	if *flagJson {
+		printJSON(list, *flagJSONMode)
+		return
+	}
+
+	for _, d := range list {
+		enabled := "off"
+		if d.Enabled {
+			enabled = "on"
+		}
+		by := "always"
+		if len(d.Flag) > 0 {
+			by = "-" + d.Flag
+		}
+		id := d.ID
+		if id == CategoryNone {
+			id = "?" /* rule w/o CategoryLister */
+		}
+		fmt.Printf("%-18s %-4s %-17s %-8s %s\n", id, enabled, by, d.Severity, d.Name)
+	}
+}
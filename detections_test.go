@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+type customRule struct{}
+
+func (customRule) Check(create, use *Inode) (Report, bool) {
+	return Report{}, false
+}
+
+type customCategoryRule struct{ customRule }
+
+func (customCategoryRule) Categories() []Category {
+	return []Category{"custom-mismatch", CategoryPathMismatch}
+}
+
+func TestListDetections(t *testing.T) {
+	tm := NewTimeline()
+	tm.AddRule(customRule{})
+	tm.AddRule(customCategoryRule{})
+
+	found := make(map[Category]Detection)
+	for _, d := range listDetections(&tm) {
+		if _, ok := found[d.ID]; ok {
+			t.Errorf("%q listed twice", d.ID)
+		}
+		found[d.ID] = d
+	}
+	for _, d := range categories {
+		if _, ok := found[d.ID]; !ok {
+			t.Errorf("category %q not listed", d.ID)
+		}
+	}
+	for _, id := range []Category{CategoryNone, "custom-mismatch", CategoryPathMismatch} {
+		if !found[id].Enabled {
+			t.Errorf("%q of added rule not listed as enabled", id)
+		}
+	}
+	if found[CategoryPathMismatch].Name != "use reached inode by another name" {
+		t.Errorf("built-in category listed as %q", found[CategoryPathMismatch].Name)
+	}
+	if found[CategoryContextMismatch].Enabled {
+		t.Errorf("%q listed as enabled w/o -selinux", CategoryContextMismatch)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "fmt"
+
+// Print the creates or uses of an event instead of correlating them, to check
+// how nametypes are classified. See -onlycreates & -onlyuses.
+func dumpInodes(rs *Records) {
+	for i := range rs.InodeSeq() {
+		if ignored.Match(&i) {
+			continue
+		}
+		if !onlySyscalls.Empty() && !onlySyscalls.Contains(i.Syscall) {
+			continue
+		}
+
+		switch nametypeOps[i.Operation] {
+		case OpCreate:
+			if *flagOnlyCreates {
+				fmt.Printf("CREATE%v\n", &i)
+			}
+		case OpUse:
+			if *flagOnlyUses {
+				fmt.Printf("USE%v\n", &i)
+			}
+		}
+	}
+}
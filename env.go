@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Prefix of env vars setting flags, ex. NCMONITOR_JSON=true for -json
+const envPrefix = "NCMONITOR_"
+
+// Names of flags given on the command line
+func givenFlags() map[string]bool {
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	return given
+}
+
+// Set flags that weren't given from their env vars. Only one file can be
+// given by NCMONITOR_FILE.
+func flagsFromEnv(given map[string]bool) error {
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if given[f.Name] || err != nil {
+			return
+		}
+
+		name := envPrefix + strings.ToUpper(f.Name)
+		if value, ok := os.LookupEnv(name); ok {
+			if e := flag.Set(f.Name, value); e != nil {
+				err = fmt.Errorf("invalid %s: %v", name, e)
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,27 @@
+package main
+
+import "time"
+
+// Metadata wrapped around json output with -envelope, so archived outputs
+// tell which run & input they came from
+type Envelope struct {
+	Tool        string   `json:"tool"`
+	Version     string   `json:"version"`
+	Files       []string `json:"files"` // -file & -tar inputs
+	GeneratedAt string   `json:"generated_at"`
+	Findings    any      `json:"findings"` // reports, or groups with -groupby
+}
+
+func NewEnvelope(findings any) Envelope {
+	var files []string
+	for _, f := range append(append(logFiles{}, flagLogfiles...), flagTars...) {
+		files = append(files, f.Path)
+	}
+	return Envelope{
+		Tool:        toolName(),
+		Version:     version(),
+		Files:       files,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Findings:    findings,
+	}
+}
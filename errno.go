@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"strconv"
+)
+
+// Names of common errno values on Linux (see errno(3))
+var errnoNames = map[int64]string{
+	1:   "EPERM",
+	2:   "ENOENT",
+	3:   "ESRCH",
+	4:   "EINTR",
+	5:   "EIO",
+	6:   "ENXIO",
+	7:   "E2BIG",
+	8:   "ENOEXEC",
+	9:   "EBADF",
+	10:  "ECHILD",
+	11:  "EAGAIN",
+	12:  "ENOMEM",
+	13:  "EACCES",
+	14:  "EFAULT",
+	16:  "EBUSY",
+	17:  "EEXIST",
+	18:  "EXDEV",
+	19:  "ENODEV",
+	20:  "ENOTDIR",
+	21:  "EISDIR",
+	22:  "EINVAL",
+	23:  "ENFILE",
+	24:  "EMFILE",
+	25:  "ENOTTY",
+	26:  "ETXTBSY",
+	27:  "EFBIG",
+	28:  "ENOSPC",
+	29:  "ESPIPE",
+	30:  "EROFS",
+	31:  "EMLINK",
+	32:  "EPIPE",
+	36:  "ENAMETOOLONG",
+	38:  "ENOSYS",
+	39:  "ENOTEMPTY",
+	40:  "ELOOP",
+	61:  "ENODATA",
+	95:  "EOPNOTSUPP",
+	122: "EDQUOT",
+}
+
+// Convert a negative syscall exit value to errno name, ex. -13 => EACCES
+func errnoName(exit int64) (string, bool) {
+	if exit >= 0 {
+		return "", false
+	}
+	name, ok := errnoNames[-exit]
+	return name, ok
+}
+
+// Parse a raw exit value. Errnos are usually logged signed (ex. -13), but
+// an unsigned value wraps around to the negative errno.
+func parseExit(v string) int64 {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return n
+	}
+	if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+		return int64(n) // ex. 18446744073709551603 => -13
+	}
+	return 0
+}
+
+// Name of the errno a failed syscall returned, ex. EACCES
+func (s Syscall) Errno() (string, bool) {
+	if s.Success {
+		return "", false
+	}
+
+	exit := s.Exit
+	// 32-bit syscalls may log an errno as unsigned, ex. 4294967283 for -13
+	if exit > 0 && exit <= math.MaxUint32 && int32(exit) < 0 {
+		exit = int64(int32(exit))
+	}
+	return errnoName(exit)
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestErrno(t *testing.T) {
+	tests := []struct {
+		exit, success string
+		want          string
+	}{
+		{"18446744073709551603", "no", "EACCES"},
+		{"-13", "no", "EACCES"},
+		{"-2", "no", "ENOENT"},
+		{"4294967283", "no", "EACCES"}, // 32-bit
+		{"-13", "yes", ""},
+		{"3", "no", ""},
+		{"", "no", ""},
+	}
+	for _, tt := range tests {
+		s := Syscall{Exit: parseExit(tt.exit), Success: tt.success == "yes"}
+		got, ok := s.Errno()
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Errno() of exit=%s success=%s = %q, %v; want %q", tt.exit, tt.success, got, ok, tt.want)
+		}
+	}
+}
+
+func TestParseExit(t *testing.T) {
+	tests := map[string]int64{
+		"18446744073709551603": -13,
+		"-13":                  -13,
+		"3":                    3,
+		"":                     0,
+		"junk":                 0,
+	}
+	for v, want := range tests {
+		if got := parseExit(v); got != want {
+			t.Errorf("parseExit(%q) = %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestSyscallStatus(t *testing.T) {
+	tests := []struct {
+		s    Syscall
+		want string
+	}{
+		{Syscall{Msg: "audit(1.0:1)", Name: "open", Exit: -13}, "open:fail(-13)"},
+		{Syscall{Msg: "audit(1.0:1)", Name: "open", Success: true}, "open"},
+		{Syscall{}, "syscall=0"}, // event w/o a SYSCALL record
+	}
+	for _, tt := range tests {
+		if got := tt.s.String(); got != tt.want {
+			t.Errorf("String() of %+v = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Arguments of an execve from its EXECVE records. Like other untrusted
+// strings, arguments are quoted, or hex-encoded if they have special
+// characters (ex. spaces). Long arguments are split in chunks, as in
+// a1_len=9000 a1[0]=... a1[1]=..., and many arguments span several records.
+func ExecveArgs(records []Record) []string {
+	body := make(map[string]string)
+	interpreted, verbatim := false, false
+	for _, r := range records {
+		for k, v := range r.Body {
+			body[k] = v
+		}
+		interpreted = interpreted || r.Interpreted
+		verbatim = verbatim || r.Verbatim
+	}
+
+	// argc is untrusted; each argument has at least one field
+	argc, err := strconv.Atoi(body["argc"])
+	if err != nil || argc < 0 || argc > len(body) {
+		return nil
+	}
+
+	decode := decodeAuditStr
+	if interpreted || verbatim { /* interpreted & json logs have decoded strings */
+		decode = func(s string) string { return strings.Trim(s, "\"") }
+	}
+
+	var args []string
+	for n := 0; n < argc; n++ {
+		key := fmt.Sprintf("a%d", n)
+
+		if value, ok := body[key]; ok {
+			args = append(args, decode(value))
+			continue
+		}
+
+		// chunks are quoted or hex-encoded on their own
+		var chunks strings.Builder
+		for c := 0; ; c++ {
+			chunk, ok := body[fmt.Sprintf("%s[%d]", key, c)]
+			if !ok {
+				break
+			}
+			chunks.WriteString(decode(chunk))
+		}
+		args = append(args, chunks.String())
+	}
+	return args
+}
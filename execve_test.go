@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExecveArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			"quoted",
+			[]string{`type=EXECVE msg=audit(1626882755.001:1): argc=2 a0="ls" a1="-l"`},
+			[]string{"ls", "-l"},
+		},
+		{
+			"hex with space",
+			[]string{`type=EXECVE msg=audit(1626882755.001:1): argc=3 a0="touch" a1=612062 a2="c"`},
+			[]string{"touch", "a b", "c"},
+		},
+		{
+			"hex in chunks",
+			[]string{`type=EXECVE msg=audit(1626882755.001:1): argc=2 a0="echo" a1_len=22 a1[0]=68656C6C6F a1[1]=20776F726C64`},
+			[]string{"echo", "hello world"},
+		},
+		{
+			"quoted & hex chunks",
+			[]string{`type=EXECVE msg=audit(1626882755.001:1): argc=2 a0="echo" a1_len=12 a1[0]="hello" a1[1]=20776F726C64`},
+			[]string{"echo", "hello world"},
+		},
+		{
+			"negative argc",
+			[]string{`type=EXECVE msg=audit(1626882755.001:1): argc=-1 a0="ls"`},
+			nil,
+		},
+		{
+			"argc beyond fields",
+			[]string{`type=EXECVE msg=audit(1626882755.001:1): argc=2147483647 a0="ls"`},
+			nil,
+		},
+		{
+			"across records",
+			[]string{
+				`type=EXECVE msg=audit(1626882755.001:1): argc=2 a0="cat"`,
+				`type=EXECVE msg=audit(1626882755.001:1): a1=2F746D702F612062`,
+			},
+			[]string{"cat", "/tmp/a b"},
+		},
+	}
+	for _, tt := range tests {
+		var records []Record
+		for _, line := range tt.lines {
+			records = append(records, NewRecord(line))
+		}
+		if got := ExecveArgs(records); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
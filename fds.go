@@ -0,0 +1,195 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AT_FDCWD as logged in a0 of *at syscalls
+const AT_FDCWD = -100
+
+// Syscalls opening a file & returning its fd in exit (x86_64)
+var openSyscalls = map[string]uint64{
+	"open":    2,
+	"creat":   85,
+	"openat":  257,
+	"openat2": 437,
+}
+
+// Syscalls whose a0 is the fd of an open file (x86_64)
+var fdSyscalls = map[string]uint64{
+	"read":         0,
+	"write":        1,
+	"fstat":        5,
+	"lseek":        8,
+	"pread64":      17,
+	"pwrite64":     18,
+	"readv":        19,
+	"writev":       20,
+	"fcntl":        72,
+	"flock":        73,
+	"fsync":        74,
+	"fdatasync":    75,
+	"ftruncate":    77,
+	"getdents":     78,
+	"fchdir":       81,
+	"fchmod":       91,
+	"fchown":       93,
+	"fstatfs":      138,
+	"fsetxattr":    190,
+	"fgetxattr":    193,
+	"flistxattr":   196,
+	"fremovexattr": 199,
+	"getdents64":   217,
+}
+
+// Syscalls whose a0 is the fd of a directory that relative paths are
+// resolved against (x86_64)
+var dirfdSyscalls = map[string]uint64{
+	"openat":            257,
+	"mkdirat":           258,
+	"mknodat":           259,
+	"fchownat":          260,
+	"futimesat":         261,
+	"newfstatat":        262,
+	"unlinkat":          263,
+	"renameat":          264,
+	"linkat":            265,
+	"readlinkat":        267,
+	"fchmodat":          268,
+	"faccessat":         269,
+	"utimensat":         280,
+	"name_to_handle_at": 303,
+	"renameat2":         316,
+	"execveat":          322,
+	"statx":             332,
+	"openat2":           437,
+	"faccessat2":        439,
+}
+
+// Is s one of syscalls? Numbers are used for logs w/o syscall names.
+func syscallIn(syscalls map[string]uint64, s Syscall) bool {
+	if len(s.Name) > 0 {
+		_, ok := syscalls[s.Name]
+		return ok
+	}
+	for _, num := range syscalls {
+		if num == s.Number {
+			return true
+		}
+	}
+	return false
+}
+
+// An fd of a process
+type fdKey struct {
+	Pid, Fd int64
+}
+
+// Files opened by each process, keyed by their fd. See -trackfds.
+type FdTable map[fdKey]Inode
+
+// Track fds opened & closed in an event, and report if an fd used in the
+// event resolves to another inode than the one it was opened for.
+func (tm *Timeline) trackFds(rs *Records) {
+	s, ok := rs.Syscall()
+	if !ok || !s.Success {
+		return
+	}
+
+	// fds are logged as 32-bit ints, ex. AT_FDCWD is ffffff9c
+	fd := int64(int32(s.A0))
+
+	if syscallIn(fdSyscalls, s) {
+		for use := range rs.InodeSeq() {
+			tm.verifyFd(fdKey{s.Pid, fd}, &use)
+			break
+		}
+	} else if syscallIn(dirfdSyscalls, s) && fd != AT_FDCWD {
+		// only relative paths are resolved against dirfd
+		for use := range rs.InodeSeq() {
+			if use.Operation == "PARENT" && !strings.HasPrefix(use.Path, "/") {
+				tm.verifyFd(fdKey{s.Pid, fd}, &use)
+				break
+			}
+		}
+	}
+
+	switch {
+	case syscallIn(openSyscalls, s) && s.Exit >= 0:
+		// opened file is the last non-parent path
+		var opened *Inode
+		for i := range rs.InodeSeq() {
+			if i.Operation != "PARENT" {
+				opened = &i
+			}
+		}
+		if opened != nil {
+			tm.verifyProcFd(s.Pid, opened)
+			tm.fds[fdKey{s.Pid, s.Exit}] = *opened
+		}
+	case s.Name == "close" || (len(s.Name) == 0 && s.Number == 3):
+		delete(tm.fds, fdKey{s.Pid, fd})
+	}
+}
+
+func (tm *Timeline) verifyFd(key fdKey, use *Inode) {
+	opened, ok := tm.fds[key]
+	if !ok || use.Name() == NoName || opened.Name() == use.Name() {
+		return
+	}
+	tm.Report(NewReport(CategoryFdMismatch, &opened, use))
+}
+
+// Paths re-opening an fd through procfs, ex. /proc/self/fd/3
+var procFdPath = regexp.MustCompile(`^(?:/proc/(self|thread-self|\d+)(?:/task/\d+)?|/dev)/fd/(\d+)$`)
+
+// The fd re-opened by path, if it's an fd path of procfs. pid is of the
+// process opening it, for /proc/self.
+func procFd(path string, pid int64) (fdKey, bool) {
+	m := procFdPath.FindStringSubmatch(path)
+	if m == nil {
+		return fdKey{}, false
+	}
+	fd, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return fdKey{}, false
+	}
+	if owner, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+		pid = owner
+	}
+	return fdKey{pid, fd}, true
+}
+
+// Report if an fd re-opened through procfs reaches another inode than the fd
+// was opened for, i.e. the file opened by its path was swapped since.
+func (tm *Timeline) verifyProcFd(pid int64, opened *Inode) {
+	key, ok := procFd(opened.NormalizedPath(), pid)
+	if !ok {
+		return
+	}
+	orig, ok := tm.fds[key]
+	if !ok || opened.Name() == NoName || orig.Name() == opened.Name() {
+		return
+	}
+	tm.Report(NewReport(CategoryProcFdMismatch, &orig, opened))
+}
+
+// Syscalls whose second path is relative to a2 (x86_64)
+var dirfd2Syscalls = map[string]uint64{
+	"renameat":  264,
+	"linkat":    265,
+	"renameat2": 316,
+}
+
+// Does the syscall resolve relative paths against a dirfd instead of cwd?
+func (s Syscall) UsesDirfd() bool {
+	if !syscallIn(dirfdSyscalls, s) {
+		return false
+	}
+	if int32(s.A0) != AT_FDCWD {
+		return true
+	}
+	return syscallIn(dirfd2Syscalls, s) && int32(s.A2) != AT_FDCWD
+}
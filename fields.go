@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Value of a column for -fields
+type fieldFunc func(r Report) string
+
+// Columns for -fields; exe, syscall, pid, tty & ses are of the use
+var reportFields = map[string]fieldFunc{
+	"category":       func(r Report) string { return string(r.Category) },
+	"severity":       func(r Report) string { return r.Severity().String() },
+	"host":           func(r Report) string { return r.Host },
+	"delta":          func(r Report) string { return r.Delta },
+	"time":           func(r Report) string { return r.Use.TimeLabel() },
+	"inode":          func(r Report) string { return createField(r, Inode.Name) },
+	"create_path":    func(r Report) string { return createField(r, Inode.NormalizedPath) },
+	"create_exe":     func(r Report) string { return createField(r, func(i Inode) string { return knownExe(i.Exe) }) },
+	"create_syscall": func(r Report) string { return createField(r, func(i Inode) string { return i.Syscall.String() }) },
+	"create_pid": func(r Report) string {
+		return createField(r, func(i Inode) string { return fmt.Sprint(i.Syscall.Pid) })
+	},
+	"create_msg": func(r Report) string { return createField(r, func(i Inode) string { return i.Msg }) },
+	"use_path":   func(r Report) string { return r.Use.NormalizedPath() },
+	"path_diff":  reportPathDiff,
+	"use_msg":    func(r Report) string { return r.Use.Msg },
+	"exe":        func(r Report) string { return knownExe(r.Use.Exe) },
+	"syscall":    func(r Report) string { return r.Use.Syscall.String() },
+	"pid":        func(r Report) string { return fmt.Sprint(r.Use.Syscall.Pid) },
+	"tty":        func(r Report) string { return r.Use.Syscall.Tty },
+	"ses":        func(r Report) string { return r.Use.Syscall.Ses },
+}
+
+// Value of a field of the create; empty for config changes, see KindConfig
+func createField(r Report, get func(Inode) string) string {
+	if r.Kind != KindPair {
+		return ""
+	}
+	return get(*r.Create)
+}
+
+// Set from -fields
+var outputFields []string
+
+func fieldNames() []string {
+	var names []string
+	for name := range reportFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse comma-separated list of columns, ex. "exe,use_path,create_path"
+func parseFields(list string) ([]string, error) {
+	var fields []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := reportFields[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// Print columns of a report, tab-separated
+func printFields(r Report, fields []string) {
+	values := make([]string, len(fields))
+	for i, name := range fields {
+		values[i] = reportFields[name](r)
+	}
+	fmt.Println(strings.Join(values, "\t"))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Log file to parse, optionally labelled with the host it was collected from
+type LogFileArg struct {
+	Host string
+	Path string
+}
+
+// Holds repeated -file values of the form "path" or "host=path"
+type logFiles []LogFileArg
+
+func (fs *logFiles) String() string {
+	var strs []string
+	for _, f := range *fs {
+		if len(f.Host) > 0 {
+			strs = append(strs, f.Host+"="+f.Path)
+		} else {
+			strs = append(strs, f.Path)
+		}
+	}
+	return strings.Join(strs, ",")
+}
+
+// Host labels are hostnames, so "./a=b" is a path
+var hostLabel = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func (fs *logFiles) Set(v string) error {
+	f := LogFileArg{Path: v}
+
+	// an existing file is a path even if it has a "=", ex. "audit=1.log"
+	if _, err := os.Stat(v); err != nil {
+		if kv := strings.SplitN(v, "=", 2); len(kv) == 2 && hostLabel.MatchString(kv[0]) {
+			f.Host, f.Path = kv[0], kv[1]
+		}
+	}
+
+	*fs = append(*fs, f)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogFilesSet(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	if err := os.WriteFile("audit=1.log", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		arg  string
+		want LogFileArg
+	}{
+		{"audit.log", LogFileArg{Path: "audit.log"}},
+		{"host1=audit.log", LogFileArg{Host: "host1", Path: "audit.log"}},
+		{"web.example.com=/var/log/a.log", LogFileArg{Host: "web.example.com", Path: "/var/log/a.log"}},
+		{"audit=1.log", LogFileArg{Path: "audit=1.log"}}, // existing file
+		{"./a=b", LogFileArg{Path: "./a=b"}},
+		{filepath.Join(dir, "x=y"), LogFileArg{Path: filepath.Join(dir, "x=y")}},
+		{"=a.log", LogFileArg{Path: "=a.log"}},
+	}
+	for _, tt := range tests {
+		var fs logFiles
+		fs.Set(tt.arg)
+		if len(fs) != 1 || fs[0] != tt.want {
+			t.Errorf("Set(%q) = %+v, want %+v", tt.arg, fs, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Name of the database/sql driver for -sqlite. Set by sqlite.go, which is only
+// built with "-tags sqlite", so the default binary doesn't link SQLite.
+var sqliteDriver string
+
+const findingsSchema = `CREATE TABLE IF NOT EXISTS findings (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	host           TEXT,
+	category       TEXT,
+	severity       TEXT,
+	inode          TEXT,
+	create_msg     TEXT,
+	create_path    TEXT,
+	create_exe     TEXT,
+	create_syscall TEXT,
+	use_msg        TEXT,
+	use_path       TEXT,
+	use_exe        TEXT,
+	use_syscall    TEXT,
+	timestamp      TEXT,
+	delta          TEXT,
+	config         TEXT
+)`
+
+// Findings stored in a SQLite database, see -sqlite
+type FindingsDB struct {
+	db *sql.DB
+}
+
+// Open database, creating its schema on first use
+func OpenFindingsDB(file string) (*FindingsDB, error) {
+	if len(sqliteDriver) == 0 {
+		return nil, fmt.Errorf("built without SQLite; rebuild with -tags sqlite")
+	}
+
+	db, err := sql.Open(sqliteDriver, file)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(findingsSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &FindingsDB{db: db}, nil
+}
+
+// Insert a report. Config changes (see KindConfig) have config set instead of
+// the create columns.
+func (f *FindingsDB) Insert(r Report) error {
+	var inode, cMsg, cPath, cExe, cSyscall string
+	if r.Kind == KindPair {
+		inode = r.Create.Name()
+		cMsg, cPath = r.Create.Msg, r.Create.NormalizedPath()
+		cExe, cSyscall = r.Create.Exe, r.Create.Syscall.String()
+	}
+
+	_, err := f.db.Exec(`INSERT INTO findings (host, category, severity, inode,
+		create_msg, create_path, create_exe, create_syscall,
+		use_msg, use_path, use_exe, use_syscall, timestamp, delta, config)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Host, string(r.Category), r.Severity().String(), inode,
+		cMsg, cPath, cExe, cSyscall,
+		r.Use.Msg, r.Use.NormalizedPath(), r.Use.Exe, r.Use.Syscall.String(),
+		r.Use.Timestamp, r.Delta, r.Config)
+	return err
+}
+
+func (f *FindingsDB) Close() error {
+	return f.db.Close()
+}
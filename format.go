@@ -0,0 +1,208 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Supported log formats
+
+Raw logs are produced by "ausearch" or "ausearch --format raw" and are the
+preferred input. Interpreted logs are produced by "ausearch -i"; their values
+are converted back to their raw representation on a best-effort basis.
+
+Other formats like "ausearch --format text" or "aureport" summaries lack inode
+numbers, which are needed to find bad create-use pairs, and are rejected.
+*/
+const (
+	FormatRaw         = "raw"
+	FormatInterpreted = "interpreted"
+	FormatUnsupported = "unsupported"
+)
+
+const formatHelp = `supported formats are raw records ("ausearch --raw" or "ausearch --format raw")
+and interpreted records ("ausearch -i"); "ausearch --format text" and aureport
+output don't have inode numbers and cannot be analyzed`
+
+// Detect the format of a single auditd line (excluding "----" & "time->")
+func DetectFormat(line string) string {
+	header := strings.SplitN(line, ": ", 2)[0]
+	if !strings.Contains(header, "type=") {
+		return FormatUnsupported
+	}
+
+	// raw:         msg=audit(1626882755.114:10942)
+	// interpreted: msg=audit(07/07/2021 14:54:51.223:671)
+	idx := strings.Index(header, "msg=audit(")
+	if idx < 0 {
+		return FormatUnsupported
+	}
+	if strings.Contains(header[idx:], "/") {
+		return FormatInterpreted
+	}
+	return FormatRaw
+}
+
+// Values of symbolic syscall arguments (x86_64); see open(2) & openat(2)
+var symbolicArgs = map[string]uint64{
+	"AT_FDCWD":    0xffffff9c,
+	"O_RDONLY":    0,
+	"O_WRONLY":    01,
+	"O_RDWR":      02,
+	"O_CREAT":     0100,
+	"O_EXCL":      0200,
+	"O_NOCTTY":    0400,
+	"O_TRUNC":     01000,
+	"O_APPEND":    02000,
+	"O_NONBLOCK":  04000,
+	"O_DSYNC":     010000,
+	"O_LARGEFILE": 0100000,
+	"O_DIRECTORY": 0200000,
+	"O_NOFOLLOW":  0400000,
+	"O_NOATIME":   01000000,
+	"O_CLOEXEC":   02000000,
+	"O_SYNC":      04010000,
+	"O_PATH":      010000000,
+	"O_TMPFILE":   020200000,
+}
+
+// Convert interpreted syscall argument, ex. "0x55bc" or "O_RDONLY|O_CREAT".
+// Unknown symbols are ignored.
+func interpretedArg(v string) uint64 {
+	if n, err := strconv.ParseUint(strings.TrimPrefix(v, "0x"), 16, 64); err == nil {
+		return n
+	}
+
+	var n uint64
+	for _, sym := range strings.Split(v, "|") {
+		n |= symbolicArgs[sym]
+	}
+	return n
+}
+
+// Convert interpreted exit value, ex. "3" or "ENOENT(No such file or directory)"
+func interpretedExit(v string) int64 {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return n
+	}
+
+	name := strings.SplitN(v, "(", 2)[0]
+	for num, errno := range errnoNames {
+		if errno == name {
+			return -num
+		}
+	}
+	return 0
+}
+
+// Convert interpreted syscall name to its number using ausyscall, or the
+// built-in table of path syscalls
+func interpretedSyscall(name string) uint64 {
+	for num, n := range AuSyscalls {
+		if n == name {
+			number, _ := strconv.ParseUint(num, 10, 64)
+			return number
+		}
+	}
+	return pathSyscalls[name]
+}
+
+// File types in interpreted modes (see man 7 inode)
+var interpretedFileTypes = map[string]uint64{
+	"socket":    0140000,
+	"link":      0120000,
+	"file":      0100000,
+	"block":     060000,
+	"dir":       040000,
+	"character": 020000,
+	"fifo":      010000,
+}
+
+// Convert interpreted mode to raw, ex. "dir,775" => "040775"
+func interpretedMode(v string) string {
+	parts := strings.SplitN(v, ",", 2)
+	if len(parts) != 2 {
+		return v
+	}
+
+	ftype, ok := interpretedFileTypes[parts[0]]
+	if !ok {
+		return v
+	}
+	perm, err := strconv.ParseUint(parts[1], 8, 64)
+	if err != nil {
+		return v
+	}
+	return "0" + strconv.FormatUint(ftype|perm, 8)
+}
+
+// Parse time from the msg field, ex. "audit(1626882755.118:10946)" (raw) or
+// "audit(07/07/2021 14:54:51.231:675)" (interpreted, local time).
+func parseMsgTime(msg string) (time.Time, bool) {
+	msg = strings.TrimSpace(msg)
+	msg = strings.TrimPrefix(msg, "audit(")
+	msg = strings.TrimSuffix(msg, ")")
+
+	// drop serial
+	idx := strings.LastIndex(msg, ":")
+	if idx < 0 {
+		return time.Time{}, false
+	}
+	stamp := msg[:idx]
+
+	// interpreted
+	if strings.Contains(stamp, "/") {
+		t, err := time.ParseInLocation("01/02/2006 15:04:05.000", stamp, time.Local)
+		return t, err == nil
+	}
+
+	// raw: seconds.milliseconds since epoch
+	parts := strings.SplitN(stamp, ".", 2)
+	sec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var msec int64
+	if len(parts) == 2 {
+		msec, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+	return time.Unix(sec, msec*int64(time.Millisecond)), true
+}
+
+// Zone times are shown in, see -tz
+var outputLocation = time.UTC
+
+// Layout of times shown in output, in -tz
+const timeLabelLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Time of the syscall as shown in output, ex. "2021-07-21T11:52:35.118Z".
+// Empty if msg has no parsable time.
+func (i Inode) TimeLabel() string {
+	t, ok := i.Time()
+	if !ok {
+		return ""
+	}
+	return t.In(outputLocation).Format(timeLabelLayout)
+}
+
+// Serial of the event from msg, ex. "10947" for audit(1626882755.122:10947)
+func parseMsgSerial(msg string) string {
+	msg = strings.TrimSuffix(strings.TrimSpace(msg), ")")
+	return msg[strings.LastIndex(msg, ":")+1:]
+}
+
+// msg of a record line w/o parsing it, ex. "audit(1626882755.122:10947)".
+// Empty if there's none, ex. for "time->" lines.
+func lineMsg(line string) string {
+	start := strings.Index(line, "msg=audit(")
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(line[start:], ")")
+	if end < 0 {
+		return ""
+	}
+	return line[start+len("msg=") : start+end+1]
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Escape data of GitHub Actions workflow commands
+var ghEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// Escape properties of GitHub Actions workflow commands
+var ghPropEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// Print report as a GitHub Actions warning annotation. Audit logs have no
+// file or line, so only the title & message are set.
+func printAnnotation(r Report) {
+	title := fmt.Sprintf("Name confusion (%s)", r.Category)
+	if len(r.Host) > 0 {
+		title += " on " + r.Host
+	}
+
+	if r.Kind == KindConfig {
+		fmt.Printf("::warning title=%s::%s\n", ghPropEscaper.Replace(title), ghEscaper.Replace(r.Config))
+		return
+	}
+
+	msg := fmt.Sprintf("'%s' created %s (%s); '%s' used it as %s (%s); inode %s",
+		r.Create.Attribution(), r.Create.NormalizedPath(), r.Create.Syscall,
+		r.Use.Attribution(), r.Use.NormalizedPath(), r.Use.Syscall,
+		r.Use.Name())
+
+	fmt.Printf("::warning title=%s::%s\n", ghPropEscaper.Replace(title), ghEscaper.Replace(msg))
+}
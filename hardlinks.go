@@ -0,0 +1,54 @@
+package main
+
+// Syscalls giving an inode another name (x86_64)
+var linkSyscalls = map[string]uint64{
+	"link":   86,
+	"linkat": 265,
+}
+
+// Names of inodes made by link(), keyed by inode name
+type LinkTable map[string]map[string]bool
+
+// Remember both names of an inode linked in an event. The old name is a
+// NORMAL path & the new name a CREATE path of the same inode.
+func (tm *Timeline) trackLinks(rs *Records) {
+	s, ok := rs.Syscall()
+	if !ok || !s.Success || !syscallIn(linkSyscalls, s) {
+		return
+	}
+
+	var names []Inode
+	for i := range rs.InodeSeq() {
+		if i.Operation == "NORMAL" || i.Operation == "CREATE" {
+			names = append(names, i)
+		}
+	}
+
+	for _, a := range names {
+		for _, b := range names {
+			if a.Name() == b.Name() && a.Path != b.Path {
+				tm.addLink(&a)
+			}
+		}
+	}
+}
+
+func (tm *Timeline) addLink(i *Inode) {
+	name := i.Name()
+	if tm.links[name] == nil {
+		tm.links[name] = make(map[string]bool)
+	}
+	tm.links[name][i.NormalizedPath()] = true
+}
+
+// Forget a name of an inode, ex. once it's deleted
+func (tm *Timeline) removeLink(i *Inode) {
+	delete(tm.links[i.Name()], i.NormalizedPath())
+}
+
+// Are paths of a create-use pair both known names of the inode? Then the
+// pair is likely benign.
+func (tm *Timeline) isHardlink(create, use *Inode) bool {
+	names := tm.links[create.Name()]
+	return names[create.NormalizedPath()] && names[use.NormalizedPath()]
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// Valid values for -evict: evict the oldest creates, the least recently used
+// ones, or ones neither created nor used for -evictttl
+var evictPolicies = []string{"fifo", "lru", "ttl"}
+
+func validEvictPolicy(policy string) bool {
+	for _, p := range evictPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// A create recorded in history, in the order creates were recorded (or used,
+// for lru & ttl)
+type historyEntry struct {
+	name string
+	seq  uint64
+	at   time.Time // time of event, from msg
+}
+
+// Order of creates in history, so they can be evicted to bound memory. See
+// -historymax & -evict. Entries of names touched again or deleted since are
+// skipped when evicting.
+type historyOrder struct {
+	queue   []historyEntry
+	born    map[string]uint64 // seq of the latest touch of each name
+	seq     uint64
+	evicted int
+}
+
+func newHistoryOrder() historyOrder {
+	return historyOrder{born: make(map[string]uint64)}
+}
+
+// Are creates ordered for eviction? Otherwise history is unbounded & the
+// order isn't kept, to save its memory.
+func evicting() bool {
+	return *flagHistoryMax > 0 || *flagEvict == "lru" || *flagEvict == "ttl"
+}
+
+// Move a create to the back of the eviction order
+func (o *historyOrder) touch(name string, i *Inode) {
+	at, _ := i.Time()
+	o.seq++
+	o.born[name] = o.seq
+	o.queue = append(o.queue, historyEntry{name, o.seq, at})
+}
+
+// Record a create in history, evicting creates if needed. Evicted creates
+// can't be correlated with later uses, so a confusion whose use comes after
+// its create was evicted is missed; that's the price of bounded memory. With
+// -evict ttl, a use within -evictttl of its create (or of its last use) is
+// always correlated.
+func (tm *Timeline) recordHistory(name string, i *Inode) {
+	tm.history[name] = *i
+	delete(tm.used, name)
+	if evicting() {
+		tm.order.touch(name, i)
+		tm.evictHistory(i)
+	}
+}
+
+// Note a use of a create, which keeps it from being evicted with lru & ttl
+func (tm *Timeline) touchHistory(name string, use *Inode) {
+	if *flagEvict == "lru" || *flagEvict == "ttl" {
+		tm.order.touch(name, use)
+	}
+	if evicting() {
+		tm.evictHistory(use)
+	}
+}
+
+// Forget a deleted create
+func (tm *Timeline) deleteHistory(name string) {
+	delete(tm.history, name)
+	delete(tm.used, name)
+	delete(tm.order.born, name)
+}
+
+// Forget chains of inodes operated on in the event just applied that aren't
+// creates in history, ex. deleted ones. Only creates' chains are shown, and
+// a rename deletes & creates the inode in one event.
+func (tm *Timeline) pruneChains() {
+	for _, name := range tm.chained {
+		if _, ok := tm.history[name]; !ok {
+			delete(tm.chains, name)
+		}
+	}
+	tm.chained = tm.chained[:0]
+}
+
+// Evict creates over -historymax, or older than -evictttl before the event
+// of i, with their chains
+func (tm *Timeline) evictHistory(i *Inode) {
+	now, ok := i.Time()
+	tm.order.evict(tm.history, now, ok, func(name string) {
+		delete(tm.history, name)
+		delete(tm.used, name)
+		delete(tm.chains, name)
+		tm.order.evicted++
+	})
+}
+
+// Evict entries over -historymax, or older than -evictttl before now, calling
+// drop for each. Names that aren't in entries anymore are skipped.
+func (o *historyOrder) evict(entries map[string]Inode, now time.Time, timed bool, drop func(name string)) {
+	ttl := *flagEvict == "ttl" && timed
+
+	for len(o.queue) > 0 {
+		oldest := o.queue[0]
+		if o.born[oldest.name] != oldest.seq {
+			o.queue = o.queue[1:]
+			continue // touched again since
+		}
+
+		full := *flagHistoryMax > 0 && len(entries) > *flagHistoryMax
+		expired := ttl && !oldest.at.IsZero() && now.Sub(oldest.at) > *flagEvictTTL
+		if !full && !expired {
+			break
+		}
+
+		o.queue = o.queue[1:]
+		delete(o.born, oldest.name)
+		if _, ok := entries[oldest.name]; !ok {
+			continue // deleted since
+		}
+		drop(oldest.name)
+	}
+
+	// drop skipped entries once they outnumber live ones
+	if len(o.queue) > 2*len(entries)+64 {
+		var live []historyEntry
+		for _, e := range o.queue {
+			if _, ok := entries[e.name]; ok && o.born[e.name] == e.seq {
+				live = append(live, e)
+			}
+		}
+		o.queue = live
+		for name := range o.born {
+			if _, ok := entries[name]; !ok {
+				delete(o.born, name)
+			}
+		}
+	}
+}
+
+// Log size of history & creates evicted from it, for tuning -historymax
+func (tm *Timeline) logHistoryStats() {
+	log.Printf("history: %d creates recorded, %d evicted", len(tm.history), tm.order.evicted)
+}
+
+// Create recorded for an inode, ex. "00:39" & "2103", if any. With inode
+// generations (see Inode.Gen) the latest create of the inode number is
+// returned.
+func (tm *Timeline) Lookup(device, inode string) (Inode, bool) {
+	key := Inode{Device: device, InodeNum: inode}
+	if c, ok := tm.history[key.Name()]; ok {
+		return c, true
+	}
+	return tm.latestCreate(func(c *Inode) bool {
+		return c.Device == device && c.InodeNum == inode
+	})
+}
+
+// Latest create recorded for an absolute path, compared as by -compare.
+// There's no index by path, so all of history is searched.
+func (tm *Timeline) LookupPath(path string) (Inode, bool) {
+	equal := tm.equal
+	if equal == nil {
+		equal = comparators["strict"]
+	}
+	return tm.latestCreate(func(c *Inode) bool {
+		return equal(c.NormalizedPath(), path)
+	})
+}
+
+// Most recently recorded (or used, for lru & ttl) create matching match.
+// W/o eviction, creates are ordered by the serials of their events.
+func (tm *Timeline) latestCreate(match func(c *Inode) bool) (Inode, bool) {
+	var found Inode
+	var seq uint64
+	ok := false
+	for name, c := range tm.history {
+		if !match(&c) {
+			continue
+		}
+		s, tracked := tm.order.born[name]
+		if !tracked {
+			s, _ = strconv.ParseUint(parseMsgSerial(c.Msg), 10, 64)
+		}
+		if !ok || s > seq {
+			found, seq, ok = c, s, true
+		}
+	}
+	return found, ok
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// unlink(name) by /usr/bin/creator
+func deleteEvent(serial int, name, inode string) string {
+	return rawEvent(serial,
+		`syscall=87 success=yes exit=0 a0=7ffd a1=0 a2=0 a3=0 items=2 ppid=1 pid=100 auid=1000 uid=0 gid=0 euid=0 comm="creator" exe="/usr/bin/creator"`,
+		"/",
+		`name="/tmp/" inode=2 dev=08:03 mode=040777 ouid=0 ogid=0 rdev=00:00 nametype=PARENT`,
+		fmt.Sprintf(`name="%s" inode=%s dev=08:03 mode=0100644 ouid=0 ogid=0 rdev=00:00 nametype=DELETE`, name, inode))
+}
+
+// Creates deleted again, ex. temp files
+func churnLog(n int) string {
+	var events []string
+	for k := 0; k < n; k++ {
+		inode := fmt.Sprint(1000 + k)
+		events = append(events, createEvent(2*k+1, "/tmp/a", inode), deleteEvent(2*k+2, "/tmp/a", inode))
+	}
+	return rawLog(events...)
+}
+
+func TestHistoryOrderBounded(t *testing.T) {
+	tests := []struct {
+		max   int
+		evict string
+	}{
+		{0, "fifo"}, // default: order isn't kept
+		{10, "fifo"},
+		{10, "lru"},
+		{0, "ttl"},
+	}
+	for _, tt := range tests {
+		setFlag(t, flagHistoryMax, tt.max)
+		setFlag(t, flagEvict, tt.evict)
+
+		tm := NewTimeline()
+		ParseLogContent(&tm, []byte(churnLog(500)))
+		if len(tm.history) != 0 || len(tm.order.born) != 0 {
+			t.Errorf("-historymax %d -evict %s: %d creates & %d ordered names left, want none",
+				tt.max, tt.evict, len(tm.history), len(tm.order.born))
+		}
+		if len(tm.order.queue) > 64 {
+			t.Errorf("-historymax %d -evict %s: eviction queue of %d", tt.max, tt.evict, len(tm.order.queue))
+		}
+		if !evicting() && tm.order.seq != 0 {
+			t.Errorf("-historymax %d -evict %s: order kept w/o eviction", tt.max, tt.evict)
+		}
+	}
+}
+
+// Latest create of an inode number is found with & without ordering
+func TestLookupLatest(t *testing.T) {
+	withGen := func(event, gen string) string {
+		return strings.ReplaceAll(event, "dev=08:03 mode=0100644", "dev=08:03 gen="+gen+" mode=0100644")
+	}
+	log := rawLog(withGen(createEvent(1, "/tmp/a", "10"), "1"), withGen(createEvent(2, "/tmp/b", "10"), "2"))
+	for _, max := range []int{0, 10} {
+		setFlag(t, flagHistoryMax, max)
+		tm := NewTimeline()
+		ParseLogContent(&tm, []byte(log))
+		if c, ok := tm.Lookup("08:03", "10"); !ok || c.Path != "/tmp/b" {
+			t.Errorf("-historymax %d: Lookup = %q, %v; want /tmp/b", max, c.Path, ok)
+		}
+	}
+}
+
+// stat(name) by /usr/bin/checker
+func checkEvent(serial int, name, inode string) string {
+	return rawEvent(serial,
+		`syscall=4 success=yes exit=0 a0=7ffd a1=7ffe a2=0 a3=0 items=1 ppid=1 pid=300 auid=1000 uid=0 gid=0 euid=0 comm="checker" exe="/usr/bin/checker"`,
+		"/",
+		fmt.Sprintf(`name="%s" inode=%s dev=08:03 mode=0100644 ouid=0 ogid=0 rdev=00:00 nametype=NORMAL`, name, inode))
+}
+
+// Chains are only kept for creates in history
+func TestChainsPruned(t *testing.T) {
+	setFlag(t, flagChain, true)
+
+	tm := NewTimeline()
+	log := churnLog(100) + "\n" + AuditdSep + "\n" + useEvent(1000, "/etc/passwd", "99")
+	ParseLogContent(&tm, []byte(log))
+	if len(tm.chains) != 0 {
+		t.Errorf("%d chains kept for deleted or never created inodes", len(tm.chains))
+	}
+
+	setFlag(t, flagHistoryMax, 10)
+	var events []string
+	for k := 0; k < 100; k++ {
+		events = append(events, createEvent(k+1, fmt.Sprintf("/tmp/%d", k), fmt.Sprint(1000+k)))
+	}
+	tm = NewTimeline()
+	ParseLogContent(&tm, []byte(rawLog(events...)))
+	if len(tm.chains) != len(tm.history) {
+		t.Errorf("%d chains kept for %d creates in history", len(tm.chains), len(tm.history))
+	}
+}
+
+func TestChecksEvicted(t *testing.T) {
+	setFlag(t, flagWriteCheck, true)
+	setFlag(t, flagHistoryMax, 10)
+
+	var events []string
+	for k := 0; k < 100; k++ {
+		events = append(events, checkEvent(k+1, fmt.Sprintf("/tmp/%d", k), fmt.Sprint(1000+k)))
+	}
+	tm := NewTimeline()
+	ParseLogContent(&tm, []byte(rawLog(events...)))
+	if len(tm.checks) != 10 {
+		t.Errorf("%d checks kept, want -historymax 10", len(tm.checks))
+	}
+	if _, ok := tm.checks["/tmp/99"]; !ok {
+		t.Errorf("latest check evicted")
+	}
+}
+
+// A check outlives the DELETE of its inode, which is how a swap starts
+func TestCheckOutlivesDelete(t *testing.T) {
+	setFlag(t, flagWriteCheck, true)
+
+	write := strings.ReplaceAll(useEvent(4, "/tmp/c", "11"), "a2=0", "a2=241")
+	log := rawLog(checkEvent(1, "/tmp/c", "10"), deleteEvent(2, "/tmp/c", "10"), createEvent(3, "/tmp/c", "11"), write)
+	reports, _ := reportsOf(ParseLogContent, log)
+	if len(reports) != 1 || reports[0].Category != CategoryWriteAfterCheck {
+		t.Errorf("got %d reports, want a write after check", len(reports))
+	}
+}
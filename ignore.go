@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// Devices and inodes skipped by the timeline, ex. of pseudo-filesystems
+// where inode numbers are reused
+type IgnoreList struct {
+	devices map[string]bool // ex. "00:04"
+	inodes  map[string]bool // ex. "00:39|2103", see Inode.Name()
+}
+
+// Populated from -ignore & -ignorefile
+var ignored = NewIgnoreList()
+
+func NewIgnoreList() *IgnoreList {
+	return &IgnoreList{
+		devices: make(map[string]bool),
+		inodes:  make(map[string]bool),
+	}
+}
+
+// Add entry of the form "device" or "device|inode"
+func (l *IgnoreList) Add(entry string) {
+	entry = strings.TrimSpace(entry)
+	switch {
+	case len(entry) == 0:
+	case strings.Contains(entry, "|"):
+		l.inodes[entry] = true
+	default:
+		l.devices[entry] = true
+	}
+}
+
+// Add comma-separated entries
+func (l *IgnoreList) AddList(entries string) {
+	for _, entry := range strings.Split(entries, ",") {
+		l.Add(entry)
+	}
+}
+
+// Add entries from file, one per line. Lines starting with "#" are comments.
+func (l *IgnoreList) AddFile(file string) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		l.Add(line)
+	}
+	return nil
+}
+
+func (l *IgnoreList) Match(i *Inode) bool {
+	// entries don't carry the generation, see Inode.Gen
+	return l.devices[i.Device] || l.inodes[i.Device+"|"+i.InodeNum]
+}
+
+// Prefixes of names of anonymous inodes, ex. "anon_inode:[eventfd]" or
+// "/memfd:name (deleted)". They're private to a process, so aren't correlated.
+var anonPrefixes = []string{"anon_inode:", "/memfd:", "memfd:", "pipe:[", "socket:["}
+
+func (i *Inode) IsAnonymous() bool {
+	for _, prefix := range anonPrefixes {
+		if strings.HasPrefix(i.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestIsAnonymous(t *testing.T) {
+	tests := map[string]bool{
+		"anon_inode:[eventfd]":      true,
+		"anon_inode:inotify":        true,
+		"/memfd:shm (deleted)":      true,
+		"memfd:shm":                 true,
+		"pipe:[4242]":               true,
+		"socket:[4242]":             true,
+		"/tmp/anon_inode:[eventfd]": false,
+		"/tmp/pipe":                 false,
+		"":                          false,
+	}
+	for path, want := range tests {
+		i := Inode{Path: path}
+		if got := i.IsAnonymous(); got != want {
+			t.Errorf("IsAnonymous(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// Anonymous inodes aren't correlated across processes
+func TestAnonymousInode(t *testing.T) {
+	tests := []struct {
+		create, use string
+		want        int
+	}{
+		{"anon_inode:[eventfd]", "anon_inode:[EVENTFD]", 0},
+		{"/memfd:shm (deleted)", "/memfd:SHM (deleted)", 0},
+		{"/tmp/eventfd", "/tmp/EVENTFD", 1},
+	}
+	for _, tt := range tests {
+		log := rawLog(createEvent(1, tt.create, "10"), useEvent(2, tt.use, "10"))
+		if reports, _ := reportsOf(ParseLogContent, log); len(reports) != tt.want {
+			t.Errorf("%s used as %s: got %d reports, want %d", tt.create, tt.use, len(reports), tt.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+// Names in json input are taken verbatim, even if they look like hex
+func TestJSONNamesNotHexDecoded(t *testing.T) {
+	log := `{"records": [
+	{"type": "SYSCALL", "msg": "audit(1626882755.100:1)", "body": {"syscall": "257", "success": "yes", "exit": "3", "a0": "ffffff9c", "a2": "241", "pid": "10", "exe": "/usr/bin/creator"}},
+	{"type": "CWD", "msg": "audit(1626882755.100:1)", "body": {"cwd": "/tmp"}},
+	{"type": "PATH", "msg": "audit(1626882755.100:1)", "body": {"item": "0", "name": "cafe", "inode": "5", "dev": "00:01", "mode": "0100644", "nametype": "CREATE"}}]}
+{"records": [
+	{"type": "SYSCALL", "msg": "audit(1626882755.200:2)", "body": {"syscall": "257", "success": "yes", "exit": "3", "a0": "ffffff9c", "a2": "0", "pid": "11", "exe": "/usr/bin/user"}},
+	{"type": "CWD", "msg": "audit(1626882755.200:2)", "body": {"cwd": "/tmp"}},
+	{"type": "PATH", "msg": "audit(1626882755.200:2)", "body": {"item": "0", "name": "\"CAFE\"", "inode": "5", "dev": "00:01", "mode": "0100644", "nametype": "NORMAL"}}]}`
+
+	reports, _ := reportsOf(ParseJSONContent, log)
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	r := reports[0]
+	if r.Category != CategoryCaseMismatch || r.Create.Path != "cafe" || r.Use.Path != "CAFE" {
+		t.Errorf("got %s of %q & %q, want case-mismatch of cafe & CAFE", r.Category, r.Create.Path, r.Use.Path)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+)
+
+// Smallest -maxlinesize, as bufio buffers are at least this big
+const minLineSize = 16
+
+// Lines of raw logs, in the same way as splitting on "\n". Lines longer than
+// -maxlinesize are skipped instead of being buffered whole.
+type LineReader struct {
+	r    *bufio.Reader
+	max  int
+	line int  // number of the last line
+	done bool // EOF or error
+	err  error
+}
+
+func NewLineReader(r io.Reader, max int) *LineReader {
+	return &LineReader{r: bufio.NewReaderSize(r, max+1), max: max} // +1 for "\n"
+}
+
+// Next line, w/o the "\n". ok is false when there are no more lines.
+func (lr *LineReader) Next() (line string, ok bool) {
+	for !lr.done {
+		b, err := lr.r.ReadSlice('\n')
+		lr.line++
+		if err == bufio.ErrBufferFull {
+			lr.skipLine(b)
+			continue
+		}
+		if err != nil {
+			lr.done = true
+			if err != io.EOF {
+				lr.err = err
+			}
+			return string(b), true // last line
+		}
+		return string(b[:len(b)-1]), true
+	}
+	return "", false
+}
+
+// Skip the rest of a too long line, logging the event it belonged to
+func (lr *LineReader) skipLine(start []byte) {
+	where := lineMsg(string(start))
+	if len(where) == 0 {
+		where = "at unknown event"
+	}
+	log.Printf("line %d of %s is longer than -maxlinesize (%d bytes); skipped it, so the event may be incomplete",
+		lr.line, where, lr.max)
+
+	for {
+		_, err := lr.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			lr.done = true
+			if err != io.EOF {
+				lr.err = err
+			}
+		}
+		return
+	}
+}
+
+// Error reading lines, other than EOF
+func (lr *LineReader) Err() error {
+	return lr.err
+}
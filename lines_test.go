@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIgnoredLine(t *testing.T) {
+	tests := []struct {
+		line, prefix string
+		want         bool
+	}{
+		{"", "#", true},
+		{" \t", "#", true},
+		{"# note", "#", true},
+		{"  # indented note", "#", true},
+		{"// note", "//", true},
+		{"# note", "", false}, // -commentprefix ""
+		{"type=CWD msg=audit(1626882755.001:1): cwd=\"/#tmp\"", "#", false},
+		{AuditdSep, "#", false},
+	}
+	for _, tt := range tests {
+		setFlag(t, flagComment, tt.prefix)
+		if got := ignoredLine(tt.line); got != tt.want {
+			t.Errorf("ignoredLine(%q) with -commentprefix %q = %v, want %v", tt.line, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+// Comments & blank lines within events are skipped w/o ending the event
+func TestInterleavedComments(t *testing.T) {
+	var lines []string
+	for _, line := range strings.Split(rawLog(createEvent(1, "/tmp/a", "10"), useEvent(2, "/tmp/A", "10")), "\n") {
+		lines = append(lines, "# before: "+line, "   ", line)
+	}
+	log := strings.Join(lines, "\n")
+
+	reports, _ := reportsOf(ParseLogContent, log)
+	if len(reports) != 1 || reports[0].Create.Path != "/tmp/a" || reports[0].Use.Path != "/tmp/A" {
+		t.Errorf("got %d reports, want /tmp/a used as /tmp/A", len(reports))
+	}
+}
+
+func TestLineReaderMaxSize(t *testing.T) {
+	exact := strings.Repeat("a", 16)
+	long := strings.Repeat("b", 17)
+	input := exact + "\n" + long + "\nc\n"
+	var got []string
+	lines := NewLineReader(strings.NewReader(input), 16)
+	for line, ok := lines.Next(); ok; line, ok = lines.Next() {
+		got = append(got, line)
+	}
+	if want := []string{exact, "c", ""}; !slices.Equal(got, want) {
+		t.Errorf("lines of %q = %q, want %q", input, got, want)
+	}
+
+	// a last line of exactly -maxlinesize, w/o "\n"
+	lines = NewLineReader(strings.NewReader(exact), 16)
+	if line, ok := lines.Next(); !ok || line != exact {
+		t.Errorf("last line = %q, %v; want %q", line, ok, exact)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Read reports from json output of a run: an array, an -envelope or ndjson.
+// Output grouped by -groupby isn't supported.
+func readReports(file string) ([]Report, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.TrimSpace(content)
+
+	var reports []Report
+	if bytes.HasPrefix(content, []byte("[")) {
+		err = json.Unmarshal(content, &reports)
+		return reports, err
+	}
+
+	var envelope struct {
+		Findings *[]Report `json:"findings"`
+	}
+	if err := json.Unmarshal(content, &envelope); err == nil && envelope.Findings != nil {
+		return *envelope.Findings, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, len(content)+1)
+	for scanner.Scan() {
+		var r Report
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, scanner.Err()
+}
+
+// Identity of a report across runs, ex. if the same log was processed twice
+func reportID(r Report) string {
+	if r.Kind == KindConfig {
+		return fmt.Sprint(r.Category, r.Host, r.Use.Msg, r.Config)
+	}
+	return fmt.Sprint(r.Category, "|", r.Host, "|", r.Create.Msg, "|", r.Create.Name(),
+		"|", r.Use.Msg, "|", r.Use.Name(), "|", r.Use.Path)
+}
+
+// Merge json outputs of several runs, ex. of separate hosts, into one
+// report. Duplicates are dropped & each report is annotated with the file it
+// was first found in. Output is sorted & formatted as for a single run.
+func MergeReports(files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no json outputs to merge")
+	}
+
+	out := NewTimeline()
+	seen := make(map[string]bool)
+	for _, file := range files {
+		reports, err := readReports(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		for _, r := range reports {
+			id := reportID(r)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if len(r.Source) == 0 {
+				r.Source = file
+			}
+			out.ReportLater(r)
+		}
+	}
+
+	out.processPendingRepots()
+	return nil
+}
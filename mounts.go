@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Maps devices as logged by auditd (ex. "00:39") to mount points. Populated
+// from -mountinfo.
+var mountPoints map[string]string
+
+// Parse mountinfo (see man 5 proc), ex. /proc/self/mountinfo
+func LoadMountInfo(file string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	mounts := make(map[string]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+
+		dev, ok := auditDevice(fields[2])
+		if !ok {
+			continue
+		}
+		// first mount wins for bind mounts
+		if _, ok := mounts[dev]; !ok {
+			mounts[dev] = mountEscapes.Replace(fields[4])
+		}
+	}
+	return mounts, nil
+}
+
+// Special characters in mount points are octal-escaped
+var mountEscapes = strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+
+// Convert decimal major:minor to hex, as logged by auditd
+func auditDevice(majmin string) (string, bool) {
+	parts := strings.SplitN(majmin, ":", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+
+	major, err1 := strconv.ParseUint(parts[0], 10, 32)
+	minor, err2 := strconv.ParseUint(parts[1], 10, 32)
+	if err1 != nil || err2 != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%02x:%02x", major, minor), true
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// What Timeline.Apply does with an inode of a nametype
+type NametypeOp string
+
+const (
+	OpCreate NametypeOp = "create" // record in history
+	OpUse    NametypeOp = "use"    // verify against history
+	OpDelete NametypeOp = "delete" // forget from history
+	OpIgnore NametypeOp = "ignore"
+)
+
+// Operation of each nametype, see -nametypes. Nametypes missing here are
+// logged with -verbose & skipped.
+var nametypeOps = map[string]NametypeOp{
+	"CREATE":  OpCreate,
+	"PARENT":  OpUse,
+	"NORMAL":  OpUse,
+	"DELETE":  OpDelete,
+	"UNKNOWN": OpIgnore,
+}
+
+// Override operations of nametypes, ex. "PARENT=ignore,CREATE=create"
+func setNametypeOps(spec string) error {
+	for _, pair := range strings.Split(spec, ",") {
+		nametype, op, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || len(nametype) == 0 {
+			return fmt.Errorf("%q isn't nametype=op", pair)
+		}
+		switch NametypeOp(op) {
+		case OpCreate, OpUse, OpDelete, OpIgnore:
+			nametypeOps[strings.ToUpper(nametype)] = NametypeOp(op)
+		default:
+			return fmt.Errorf("unknown op %q for %s; valid: create, use, delete, ignore", op, nametype)
+		}
+	}
+	return nil
+}
+
+// Current mapping, ex. for -verbose
+func nametypeMapping() string {
+	var pairs []string
+	for nametype, op := range nametypeOps {
+		pairs = append(pairs, nametype+"="+string(op))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
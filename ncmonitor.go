@@ -24,13 +24,17 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"iter"
 	"log"
+	"math"
 	"os"
 	"os/exec"
 	"path"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -43,36 +47,127 @@ const AuditdSep string = "----"
 /* Populated via PopulateAuSyscalls() */
 var AuSyscalls map[string]string
 
+/* Set from -syscall */
+var onlySyscalls SyscallSet
+
 /* Holds command-line flags */
 var (
 	flagSamePID     = flag.Bool("samepid", false, "validate create-use within process boundary")
 	flagSameExe     = flag.Bool("sameexe", false, "validate create-use only for the same executable")
 	flagVerbose     = flag.Bool("verbose", false, "verbose output; lines starting with 'info:' are writted to stderr")
-	flagLogfile     = flag.String("file", LogFile, "auditd `logfile` to parse")
 	flagCmd         = flag.String("cmd", "", "run `<cmd>` & trace using auditd; run tool on this trace")
 	flagSaveTrace   = flag.Bool("savetrace", false, "save generated trace from -trace")
 	flagJson        = flag.Bool("json", false, "output in json")
-	flagPretty      = flag.Bool("pretty", false, "pretty-print json output")
+	flagPretty      = flag.Bool("pretty", false, "pretty-print json output; same as -jsonmode pretty")
+	flagJSONMode    = flag.String("jsonmode", "compact", "json output `mode`: compact (one-line array), pretty (indented array) or ndjson (one object per line)")
+	flagSysArgs     = flag.Bool("syscallargs", false, "add the args of known syscalls by name, decoded, as syscall_args to json output")
+	flagEnvelope    = flag.Bool("envelope", false, "wrap json output in an object with tool, version, files & generated_at, with findings in findings")
 	flagAbsPath     = flag.Bool("abspath", false, "convert paths to absolute for non-json output")
+	flagNoCwd       = flag.Bool("nocwdresolve", false, "compare paths as logged, without resolving relative paths against cwd")
 	flagLogBadOpen  = flag.Bool("logbadopen", false, "log uses of existing files with O_CREAT flag")
 	flagAusearch    = flag.String("ausearch", "", "show raw logs of using audit msg ID ex. 15451")
-	capSyscallNames bool // capability to convert syscall numbers to names
+	flagInclFailed  = flag.Bool("includefailed", false, "don't ignore failed syscalls; annotate their exit status")
+	flagInFormat    = flag.String("informat", "raw", "format of -file: raw (auditd logs) or json (array or JSONL of events)")
+	flagSort        = flag.String("sort", "chrono", "order of output: chrono, exe, path or severity")
+	flagExplain     = flag.Bool("explain", false, "explain why each create-use pair was reported")
+	flagCompare     = flag.String("compare", "strict", "how create & use paths are compared: strict, icase or symlink")
+	flagSummary     = flag.String("report", "", "instead of findings, print a summary: paths (findings per path & the exes involved, most frequent first)")
+	flagGroupWin    = flag.Duration("groupwindow", 0, "coalesce findings of the same category, paths & exes within `duration` of each other into one with a count, ex. 1s")
+	flagGroupBy     = flag.String("groupby", "", "group output by inode, exe or path")
+	flagRedact      = flag.Bool("redact", false, "hide user names in /home/<user> and matches of -redactregex in output")
+	flagRedactRegex = flag.String("redactregex", "", "with -redact, also hide path segments matching `regex`")
+	flagSingleEvent = flag.Bool("singleevent", false, "treat the whole file as a single event (for snippets without ----)")
+	flagReCreate    = flag.Bool("recreate", false, "report inodes created again under another name before being used")
+	flagIgnore      = flag.String("ignore", "", "comma-separated `devices` (ex. 00:04) or inodes (ex. 00:39|2103) to ignore")
+	flagAllowlist   = flag.String("allowlist", "", "`file` of create<TAB>use path pairs not to report, ex. written by -learn")
+	flagLearn       = flag.String("learn", "", "instead of reporting, write create<TAB>use path pairs of findings to `file`, for use with -allowlist")
+	flagPathIncl    = flag.String("pathinclude", "", "only report pairs whose create or use path matches `regex`")
+	flagPathExcl    = flag.String("pathexclude", "", "don't report pairs whose create or use path matches `regex`")
+	flagIgnoreFile  = flag.String("ignorefile", "", "`file` with devices or inodes to ignore, one per line")
+	flagMountInfo   = flag.String("mountinfo", "", "show mount points instead of devices using `mountinfo`, ex. /proc/self/mountinfo")
+	flagNoSlash     = flag.Bool("ignoretrailingslash", false, "ignore trailing \"/\" when comparing paths, even for files")
+	flagFields      = flag.String("fields", "", "only print these comma-separated `columns` of each pair, tab-separated, ex. exe,create_path,use_path")
+	flagDiffPaths   = flag.Bool("diffpaths", false, "show which path components differ between create & use")
+	flagPathsOnly   = flag.Bool("pathsonly", false, "only print absolute create & use paths of each pair, tab-separated")
+	flagPtSep       = flag.String("proctitlesep", " ", "join proctitle args with `sep` for display, ex. \" | \" to see their boundaries")
+	flagMaxPTitle   = flag.Int("maxproctitle", 0, "show proctitle in text output, truncated to `N` characters (full with -verbose & -json)")
+	flagShowMatch   = flag.Bool("showmatches", false, "also log create-use pairs that weren't reported & count them")
+	flagTraceEvent  = flag.Bool("traceevents", false, "log a one-line summary of how each event was applied")
+	flagFailFast    = flag.Bool("failfast", false, "stop at the first finding, print it & exit with status 1")
+	flagComment     = flag.String("commentprefix", "#", "skip log lines starting with `prefix`, ex. notes in hand-edited logs; \"\" to keep them")
+	flagMaxLine     = flag.Int("maxlinesize", 1<<20, "skip log lines longer than `bytes`, ex. of huge execve args, logging their event")
+	flagReplay      = flag.Bool("replay", false, "apply events of -file as if live, i.e. sleep for the time between them; ex. to test -webhook")
+	flagSpeed       = flag.Float64("speed", 1, "with -replay, replay events `N` times as fast as logged; 0 for no sleeping")
+	flagMaxEvents   = flag.Int("maxevents", 0, "stop after `N` events, ex. to sample large logs")
+	flagWebhook     = flag.String("webhook", "", "also POST findings as a json array to `url`")
+	flagWebhookN    = flag.Int("webhookbatch", 1, "findings per POST to -webhook; the rest are posted when done")
+	flagSQLite      = flag.String("sqlite", "", "also insert findings into SQLite `db` (needs a build with -tags sqlite)")
+	flagOnlyCreates = flag.Bool("onlycreates", false, "only print the creates of each event, w/o correlating them")
+	flagOnlyUses    = flag.Bool("onlyuses", false, "only print the uses of each event, w/o correlating them")
+	flagHistoryMax  = flag.Int("historymax", 0, "keep at most `N` creates, evicting the oldest; evicted creates can't be correlated")
+	flagEvict       = flag.String("evict", "fifo", "how creates are evicted from history: fifo or lru (with -historymax), or ttl (unused for -evictttl)")
+	flagEvictTTL    = flag.Duration("evictttl", time.Hour, "with -evict ttl, evict creates neither created nor used for `duration` of log time")
+	flagDumpHist    = flag.Bool("dumphistory", false, "print recorded creates as json to stderr when done, for debugging")
+	flagConfig      = flag.String("config", "", "read defaults of flags from `file` (default ~/.ncmonitor.yaml); command-line flags take precedence over it, it over env vars")
+	flagVersion     = flag.Bool("version", false, "print version & exit")
+	flagMerge       = flag.Bool("merge", false, "merge json outputs of several runs given as args into one deduplicated report & exit")
+	flagListRules   = flag.Bool("listrules", false, "list detections (categories of findings), the flags enabling them & whether they're on, then exit; json with -json")
+	flagSelfTest    = flag.Bool("selftest", false, "check detection against bundled examples & exit")
+	flagNoColor     = flag.Bool("nocolor", false, "don't color labels of text output; same as setting NO_COLOR")
+	flagGHAnnot     = flag.Bool("ghannotations", false, "output GitHub Actions ::warning:: annotations")
+	flagWatchRules  = flag.Bool("watchrules", false, "report removal of audit rules & disabling of auditing, which blind detection")
+	flagSELinux     = flag.Bool("selinux", false, "also report create-use pairs whose SELinux object contexts (obj=) differ")
+	flagPrivTrans   = flag.Bool("privtransition", false, "only report pairs where one of create & use was privileged (euid 0) & the other wasn't")
+	flagNoHardlink  = flag.Bool("ignorehardlinks", false, "don't report pairs whose paths are hardlinks made by link(), instead of marking them")
+	flagTrackFds    = flag.Bool("trackfds", false, "track open fds per process & report ops on an fd, or re-opens via /proc/*/fd, resolving to another inode")
+	flagWriteCheck  = flag.Bool("writeaftercheck", false, "report paths written to after a check (stat, access or read-only open) reached another inode")
+	flagNametypes   = flag.String("nametypes", "", "override how PATH nametypes are applied, ex. PARENT=ignore,FOO=create; ops are create, use, delete & ignore")
+	flagTZ          = flag.String("tz", "UTC", "show times in `zone`, ex. Local or Europe/Berlin; json keeps the raw msg")
+	flagParentDirs  = flag.Bool("parentdirs", false, "report uses resolved beneath another directory inode than the one created at the parent's path")
+	flagChain       = flag.Bool("chain", false, "show all operations on the inode of a pair, ex. create, renames & use, in order")
+	flagNodeHost    = flag.Bool("nodehost", false, "use node= of records as host label, so events of each node are correlated separately")
+	flagAncestry    = flag.Bool("ancestry", false, "show the exes of the use's ancestors, as far as they're in the log")
+	flagRefreshSys  = flag.Bool("refreshsyscalls", false, "get syscall names from ausyscall again instead of its cached dump")
+	flagSyscalls    = flag.String("syscall", "", "only apply inodes of these comma-separated `syscalls`, ex. open,openat or 2,257")
+	flagLogfiles    logFiles     // -file
+	flagTars        logFiles     // -tar
+	flagProgress    progressFlag // -progress
+	capSyscallNames bool         // capability to convert syscall numbers to names
 )
 
-func PopulateAuSyscalls() {
-	out, err := exec.Command("ausyscall", "--dump").Output()
-	if err != nil {
-		if *flagVerbose {
-			log.Printf("couldn't convert syscall numbers to names: %v\n", err)
+func init() {
+	flag.Var(&flagLogfiles, "file", "auditd `logfile` to parse; repeat for several files, "+
+		"label with host as host=logfile (default "+LogFile+")")
+	flag.Var(&flagTars, "tar", "parse *.log* files in tar `archive` (ex. of /var/log/audit), "+
+		"oldest first; repeat & label like -file")
+	flag.Var(&flagProgress, "progress", "print number of processed events to stderr, "+
+		"if it's a terminal; -progress=force to always print")
+}
+
+// Syscall names are read from a cached dump of ausyscall, else from
+// ausyscall, which is then cached. Without either, the built-in table of
+// x86_64 syscalls is used on amd64; elsewhere syscalls are shown by number.
+func PopulateAuSyscalls(refresh bool) {
+	output, cached := readSyscallCache()
+	if refresh || !cached {
+		out, err := exec.Command("ausyscall", "--dump").Output()
+		switch {
+		case err == nil:
+			output = string(out)
+			if err := writeSyscallCache(output); err != nil && *flagVerbose {
+				log.Printf("couldn't cache syscall names: %v", err)
+			}
+		case !cached:
+			if *flagVerbose {
+				log.Printf("couldn't get syscall names from ausyscall, using built-in names for %s: %v\n", runtime.GOARCH, err)
+			}
+			output = embeddedSyscallDump(runtime.GOARCH)
 		}
-		capSyscallNames = false
-		return
 	}
 
 	capSyscallNames = true
 	AuSyscalls = make(map[string]string)
 
-	output := string(out)
 	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		items := strings.Split(line, "\t")
@@ -104,8 +199,12 @@ func Ausearch(file, msg string) {
 }
 
 func main() {
-	PopulateAuSyscalls()
+	os.Exit(run())
+}
 
+// Run the tool, returning its exit status. Deferred cleanups, ex. of -cmd
+// traces, run before the process exits.
+func run() int {
 	/* parse cmdline args */
 	flag.Parse()
 
@@ -113,10 +212,166 @@ func main() {
 	log.SetPrefix("info: ")
 	log.SetFlags(0) // disable data & time
 
+	if *flagVersion {
+		fmt.Println(toolName(), version())
+		return 0
+	}
+
+	/* flags not given may be set by the config file, else by env vars */
+	given := givenFlags()
+	fromConfig, err := flagsFromConfig(given)
+	if err != nil {
+		log.Fatalf("invalid -config: %v", err)
+	}
+	for name := range fromConfig {
+		given[name] = true
+	}
+	if err := flagsFromEnv(given); err != nil {
+		log.Fatal(err)
+	}
+	PopulateAuSyscalls(*flagRefreshSys)
+	if len(flagLogfiles) == 0 && len(flagTars) == 0 {
+		flagLogfiles.Set(LogFile)
+	}
+
+	if !validSortOrder(*flagSort) {
+		log.Fatalf("invalid -sort %q; valid: %s", *flagSort, strings.Join(sortOrders, ", "))
+	}
+
+	if len(*flagGroupBy) > 0 && !validGroupBy(*flagGroupBy) {
+		log.Fatalf("invalid -groupby %q; valid: %s", *flagGroupBy, strings.Join(groupByKeys, ", "))
+	}
+
+	if len(*flagFields) > 0 {
+		fields, err := parseFields(*flagFields)
+		if err != nil {
+			log.Fatalf("invalid -fields: %v; valid: %s", err, strings.Join(fieldNames(), ", "))
+		}
+		outputFields = fields
+	}
+
+	loc, err := time.LoadLocation(*flagTZ)
+	if err != nil {
+		log.Fatalf("invalid -tz: %v", err)
+	}
+	outputLocation = loc
+
+	if *flagPretty {
+		*flagJSONMode = "pretty"
+	}
+	if !validJSONMode(*flagJSONMode) {
+		log.Fatalf("invalid -jsonmode %q; valid: %s", *flagJSONMode, strings.Join(jsonModes, ", "))
+	}
+	if *flagEnvelope && *flagJSONMode == "ndjson" {
+		log.Fatal("-envelope needs a json array, i.e. -jsonmode compact or pretty")
+	}
+
+	setupColor()
+
+	if *flagSpeed < 0 {
+		log.Fatalf("invalid -speed %v; must be 0 or more", *flagSpeed)
+	}
+
+	if *flagMaxLine < minLineSize {
+		log.Fatalf("invalid -maxlinesize %d; must be at least %d", *flagMaxLine, minLineSize)
+	}
+
+	if !validSummary(*flagSummary) {
+		log.Fatalf("invalid -report %q; valid: %s", *flagSummary, strings.Join(summaryKinds, ", "))
+	}
+	if *flagSummary == "paths" && *flagJSONMode == "ndjson" {
+		*flagJSONMode = "compact" // summarized when done
+	}
+
+	if len(*flagLearn) > 0 {
+		if conflicts := learnConflicts(); len(conflicts) > 0 {
+			log.Fatalf("-learn records findings instead of reporting them; it can't be combined with %s",
+				strings.Join(conflicts, ", "))
+		}
+	}
+
+	if !validEvictPolicy(*flagEvict) {
+		log.Fatalf("invalid -evict %q; valid: %s", *flagEvict, strings.Join(evictPolicies, ", "))
+	}
+
+	if _, ok := comparators[*flagCompare]; !ok {
+		log.Fatalf("invalid -compare %q; valid: %s", *flagCompare, strings.Join(comparatorNames(), ", "))
+	}
+
+	if err := setupRedaction(*flagRedactRegex); err != nil {
+		log.Fatalf("invalid -redactregex: %v", err)
+	}
+
+	if err := setupPathFilter(*flagPathIncl, *flagPathExcl); err != nil {
+		log.Fatalf("invalid %v", err)
+	}
+
+	onlySyscalls = NewSyscallSet(*flagSyscalls)
+
+	if len(*flagNametypes) > 0 {
+		if err := setNametypeOps(*flagNametypes); err != nil {
+			log.Fatalf("invalid -nametypes: %v", err)
+		}
+		if *flagVerbose {
+			log.Printf("nametypes: %s", nametypeMapping())
+		}
+	}
+
+	ignored.AddList(*flagIgnore)
+	if len(*flagIgnoreFile) > 0 {
+		if err := ignored.AddFile(*flagIgnoreFile); err != nil {
+			log.Fatalf("cannot read -ignorefile: %v", err)
+		}
+	}
+	if len(*flagAllowlist) > 0 {
+		if err := allowed.AddFile(*flagAllowlist); err != nil {
+			log.Fatalf("cannot read -allowlist: %v", err)
+		}
+	}
+
+	if len(*flagMountInfo) > 0 {
+		mounts, err := LoadMountInfo(*flagMountInfo)
+		if err != nil && *flagVerbose {
+			log.Printf("cannot map devices to mount points: %v", err)
+		}
+		mountPoints = mounts
+	}
+
+	/* "check" subcommand */
+	if flag.Arg(0) == "check" {
+		if !HealthCheck() {
+			return 1
+		}
+		return 0
+	}
+
+	/* merge json outputs given as args */
+	if *flagMerge {
+		if err := MergeReports(flag.Args()); err != nil {
+			log.Fatalf("cannot merge: %v", err)
+		}
+		return 0
+	}
+
+	/* list of detections requested */
+	if *flagListRules {
+		tm := NewTimeline()
+		printDetections(&tm)
+		return 0
+	}
+
+	/* selftest requested */
+	if *flagSelfTest {
+		if !SelfTest() {
+			return 1
+		}
+		return 0
+	}
+
 	/* ausearch requested */
 	if len(*flagAusearch) > 0 {
-		Ausearch(*flagLogfile, *flagAusearch)
-		return
+		Ausearch(flagLogfiles[0].Path, *flagAusearch)
+		return 0
 	}
 
 	/* trace cmd & run tool */
@@ -142,39 +397,239 @@ func main() {
 		}
 
 		// Get results
-		*flagLogfile = t.TraceFile
+		flagLogfiles = logFiles{{Path: t.TraceFile}}
 	}
 
 	/* main logic */
 	if *flagVerbose {
 		log.Println("Name confusion detection utility")
 	}
-	ParseLog(*flagLogfile)
+	var parse func(tm *Timeline, content []byte)
+	switch *flagInFormat {
+	case "raw":
+		parse = ParseLogContent
+	case "json":
+		parse = ParseJSONContent
+	default:
+		log.Fatalf("unknown input format: %s", *flagInFormat)
+	}
+
+	progress = NewProgress(string(flagProgress), *flagMaxEvents)
+	defer progress.Done()
+
+	found := false // by -failfast
+
+	// Inodes are only meaningful within a host, so each host gets its own
+	// timeline. Their violations are reported together.
+	out := NewTimeline()
+	defer func() {
+		out.processPendingRepots() // only collects reports
+	}()
+
+	var learned Allowlist
+	if len(*flagLearn) > 0 {
+		learned = make(Allowlist)
+		defer func() {
+			if err := learned.WriteFile(*flagLearn); err != nil {
+				log.Fatalf("cannot write -learn: %v", err)
+			}
+			log.Printf("learned %d path pairs, see %s", len(learned), *flagLearn)
+		}()
+	}
+	report, tick, closeSinks := reportChain(&out, learned, &found)
+	defer closeSinks() // after timelines are closed
+
+	var timelines []*Timeline
+	defer func() {
+		for _, tm := range timelines {
+			tm.Close() // reports were passed to out
+		}
+	}()
+	timeline := func(host string) *Timeline {
+		for _, tm := range timelines {
+			if tm.Host == host {
+				return tm
+			}
+		}
+		t := NewTimeline()
+		t.Host = host
+		t.OnReport(report)
+		t.OnEvent(tick)
+		timelines = append(timelines, &t)
+		return &t
+	}
+
+	for _, f := range flagLogfiles {
+		content, err := ReadLogFile(f.Path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		parse(timeline(f.Host), content)
+	}
+
+	for _, f := range flagTars {
+		tm := timeline(f.Host)
+		err := ReadTarLogs(f.Path, func(member string, content []byte) {
+			log.Printf("processing %s from %s", member, f.Path)
+			parse(tm, content)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if progress.Full() && !progress.stopped {
+		log.Printf("reached -maxevents after %d events; any further input wasn't processed", *flagMaxEvents)
+	}
+
+	if found {
+		return 1 // after deferred output is flushed
+	}
+	return 0
+}
+
+// Pass findings through the sinks of -failfast, -sqlite, -webhook &
+// -groupwindow to out. With -learn, findings are only recorded in learned,
+// see learnConflicts. found is set by -failfast. Returns the start of the
+// chain, a func to call after each event & a func closing its sinks.
+func reportChain(out *Timeline, learned Allowlist, found *bool) (func(Report), func(time.Time), func()) {
+	if learned != nil {
+		return learned.Add, nil, func() {}
+	}
+
+	var closers []func()
+	report := out.Emit // hosts' timelines filter their reports
+	if *flagFailFast {
+		next := report
+		report = func(r Report) {
+			if !*found {
+				*found = true
+				progress.Stop()
+				next(r)
+			}
+		}
+	}
+	if len(*flagSQLite) > 0 {
+		db, err := OpenFindingsDB(*flagSQLite)
+		if err != nil {
+			log.Fatalf("cannot open -sqlite: %v", err)
+		}
+		closers = append(closers, func() { db.Close() })
+
+		next := report
+		report = func(r Report) {
+			if err := db.Insert(r); err != nil {
+				log.Fatalf("cannot insert finding: %v", err)
+			}
+			next(r)
+		}
+	}
+	if len(*flagWebhook) > 0 {
+		hook := NewWebhook(*flagWebhook, *flagWebhookN)
+		closers = append(closers, hook.Close)
+
+		next := report
+		report = func(r Report) {
+			hook.Send(r)
+			next(r)
+		}
+	}
+	var tick func(time.Time)
+	if *flagGroupWin > 0 && !*flagFailFast { /* the first finding is all -failfast reports */
+		bursts := NewCoalescer(*flagGroupWin, report)
+		closers = append(closers, bursts.Close)
+		report, tick = bursts.Send, bursts.Tick
+	}
+
+	return report, tick, func() {
+		for n := len(closers) - 1; n >= 0; n-- {
+			closers[n]() // outermost first, so flushed findings reach the rest
+		}
+	}
+}
+
+// Flags whose sinks can't be combined with -learn, since it records findings
+// instead of reporting them
+func learnConflicts() []string {
+	var conflicts []string
+	if len(*flagWebhook) > 0 {
+		conflicts = append(conflicts, "-webhook")
+	}
+	if len(*flagSQLite) > 0 {
+		conflicts = append(conflicts, "-sqlite")
+	}
+	if *flagFailFast {
+		conflicts = append(conflicts, "-failfast")
+	}
+	if *flagGroupWin > 0 {
+		conflicts = append(conflicts, "-groupwindow")
+	}
+	return conflicts
 }
 
 // Shim to put it together
-func ParseLog(file string) {
-	content, err := ioutil.ReadFile(file)
+func ParseLog(tm *Timeline, file string) {
+	content, err := ReadLogFile(file)
 	if err != nil {
 		log.Fatal(err)
 	}
+	ParseLogContent(tm, content)
+}
 
-	contentStr := string(content)
-	lines := strings.Split(contentStr, "\n")
+// Apply raw auditd logs against the timeline
+func ParseLogContent(tm *Timeline, content []byte) {
+	lines := NewLineReader(bytes.NewReader(content), *flagMaxLine)
 
-	tm := NewTimeline() /* records of operations */
-	defer tm.Close()
 	rs := &Records{}
+	var replayer Replayer // each input is paced by its own timestamps
 
-	for _, line := range lines {
-		if line != AuditdSep {
-			rs.AddLine(line)
-		} else {
-			inodes := rs.GetInodes()
-			tm.ApplyInodes(inodes)
+	flush := func() {
+		if len(rs.Records) > 0 {
+			replayer.Wait(rs)
+			tm.ApplyRecords(rs)
+			progress.Event()
 			rs = &Records{}
 		}
 	}
+
+	for line, ok := lines.Next(); ok; line, ok = lines.Next() {
+		if progress.Full() {
+			return // -maxevents
+		}
+
+		line = strings.TrimSuffix(line, "\r") // CRLF line endings
+		if ignoredLine(line) {
+			continue // doesn't end the event
+		}
+		switch {
+		case *flagSingleEvent:
+			if line != AuditdSep {
+				rs.AddLine(line) // whole file is one event
+			}
+		case line == AuditdSep:
+			flush()
+		default:
+			// audit.log & "ausearch -r" have no separators, but records
+			// of an event are consecutive & share msg
+			if n := len(rs.Records); n > 0 {
+				if msg := lineMsg(line); len(msg) > 0 && msg != rs.Records[n-1].Msg {
+					flush()
+				}
+			}
+			rs.AddLine(line)
+		}
+	}
+
+	if err := lines.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	// last event may not be followed by a separator
+	if len(rs.Records) > 0 && !progress.Full() {
+		replayer.Wait(rs)
+		tm.ApplyRecords(rs)
+		progress.Event()
+	}
 }
 
 // Parse a string to key-value pairs
@@ -201,6 +656,17 @@ func ParseKVPairs(str string) map[string]string {
 		}
 	}
 
+	// interpreted logs (ausearch -i) have other values w/ spaces too
+	var lastKey string
+	tryAddingToLast := func(s string) bool {
+		if v, ok := result[lastKey]; ok {
+			result[lastKey] = v + " " + s
+			return true
+		} else {
+			return false
+		}
+	}
+
 	reportErr := func(e error, entry string) {
 		fmt.Println("Result = ", result)
 		errorStr := fmt.Sprintf("%v, forstring: %v, at: %v\n",
@@ -210,13 +676,19 @@ func ParseKVPairs(str string) map[string]string {
 
 	// actual parsing
 	for _, entry := range entries {
-		vals := strings.Split(entry, "=")
+		// values may have "=", ex. name="config=prod.yaml"; keys never do
+		vals := strings.SplitN(entry, "=", 2)
 
 		switch len(vals) {
 		case 2: /* expected */
 			k, v := vals[0], vals[1]
 			result[k] = v
+			lastKey = k
 		case 1:
+			if vals[0] == "" {
+				continue
+			}
+
 			ok := tryAddingToMsg(vals[0])
 			if ok {
 				continue
@@ -227,7 +699,8 @@ func ParseKVPairs(str string) map[string]string {
 				continue
 			}
 
-			if vals[0] == "" {
+			ok = tryAddingToLast(vals[0])
+			if ok {
 				continue
 			}
 			// fmt.Println(vals, len(vals))
@@ -252,11 +725,20 @@ type Record struct {
 	Msg       string
 	Timestamp string
 	Body      map[string]string
+	Node      string // host of remote logs, ex. node=web1 type=...
+
+	Interpreted bool // logged by "ausearch -i"
+	Verbatim    bool // strings are only quoted, never hex-encoded, ex. in -informat json
 }
 
 // Create a Record from raw string
 func NewRecord(rawstr string) Record {
-	lines := strings.Split(rawstr, ": ")
+	format := DetectFormat(rawstr)
+	if format == FormatUnsupported {
+		log.Fatalf("unsupported log format at line: %q\n%s", rawstr, formatHelp)
+	}
+
+	lines := strings.SplitN(rawstr, ": ", 2)
 	if len(lines) != 2 {
 		err := errors.New("Invalid format of auditd line")
 		fmt.Println(lines)
@@ -265,12 +747,22 @@ func NewRecord(rawstr string) Record {
 
 	headerRaw, bodyRaw := lines[0], lines[1]
 	headers := ParseKVPairs(headerRaw)
+
+	var avc map[string]string
+	if headers["type"] == "AVC" {
+		bodyRaw, avc = splitAVCPrefix(bodyRaw)
+	}
 	body := ParseKVPairs(bodyRaw)
+	for k, v := range avc {
+		body[k] = v
+	}
 
 	return Record{
-		Type: headers["type"],
-		Msg:  headers["msg"],
-		Body: body,
+		Type:        headers["type"],
+		Msg:         headers["msg"],
+		Node:        headers["node"],
+		Body:        body,
+		Interpreted: format == FormatInterpreted,
 	}
 }
 
@@ -280,9 +772,19 @@ type Records struct {
 	Timestamp string // Also copied to all records
 }
 
+// Is the line blank or a comment, ex. of hand-edited logs? Such lines are
+// skipped; see -commentprefix.
+func ignoredLine(line string) bool {
+	line = strings.TrimSpace(line)
+	if len(line) == 0 {
+		return true
+	}
+	return len(*flagComment) > 0 && strings.HasPrefix(line, *flagComment)
+}
+
 // Parse a raw string into Record and add it to itself
 func (rs *Records) AddLine(line string) {
-	if len(line) == 0 {
+	if ignoredLine(line) {
 		return
 	}
 
@@ -301,43 +803,147 @@ func (rs *Records) AddLines(lines []string) {
 	}
 }
 
-// Generate Inodes from a set of records representing an event.
-func (rs Records) GetInodes() *Inodes {
-	// fmt.Println(rs)
-	inodes := Inodes{}
+// Records of an event shared by all its inodes
+type eventContext struct {
+	syscall, proctitle, cwd Record
+	sockPath                string
+	execve                  []Record
+}
 
-	// Extract specific records
-	var syscall, proctitle, cwd Record
+// Extract specific records
+func (rs Records) context() eventContext {
+	var ctx eventContext
 	for _, r := range rs.Records {
 		switch r.Type {
 		case "SYSCALL":
-			syscall = r
+			ctx.syscall = r
 		case "PROCTITLE":
-			proctitle = r
+			ctx.proctitle = r
 		case "CWD":
-			cwd = r
-		case "PATH":
-		case "CONFIG_CHANGE":
+			ctx.cwd = r
+		case "SOCKADDR":
+			ctx.sockPath, _ = DecodeUnixSockaddr(r.Body["saddr"])
+		case "EXECVE":
+			ctx.execve = append(ctx.execve, r)
+		}
+	}
+	return ctx
+}
+
+// Record types used for detection
+var knownRecordTypes = map[string]bool{
+	"SYSCALL":       true,
+	"PROCTITLE":     true,
+	"CWD":           true,
+	"SOCKADDR":      true,
+	"PATH":          true,
+	"CONFIG_CHANGE": true,
+	"EXECVE":        true,
+	"AVC":           true,
+}
+
+// Log records of types not used for detection
+func (rs Records) logUnknown() {
+	for _, r := range rs.Records {
+		if !knownRecordTypes[r.Type] {
+			log.Println("unknown record type:", r)
+		}
+	}
+}
+
+// One-line summary of a non-empty event for -traceevents, ex.
+// "event 10946: openat paths=2 [use create]"
+func (rs Records) summary() string {
+	serial, syscall := parseMsgSerial(rs.Records[0].Msg), "no syscall"
+	if s, ok := rs.Syscall(); ok {
+		syscall = s.String()
+	}
+
+	var ops []string
+	for i := range rs.InodeSeq() {
+		switch i.Operation {
+		case "CREATE":
+			ops = append(ops, "create")
+		case "NORMAL", "PARENT":
+			ops = append(ops, "use")
+		case "DELETE":
+			ops = append(ops, "delete")
 		default:
-			if *flagVerbose {
-				log.Println("unknown record type:", r)
-			}
+			ops = append(ops, strings.ToLower(i.Operation))
 		}
 	}
+	return fmt.Sprintf("event %s: %s paths=%d %v", serial, syscall, len(ops), ops)
+}
 
-	// Extract inodes
+// Syscall of the event, if any
+func (rs Records) Syscall() (Syscall, bool) {
 	for _, r := range rs.Records {
-		if r.Type == "PATH" {
-			inode := NewInode(syscall, proctitle, cwd, r)
-			inodes.AddInode(inode)
-			// fmt.Println(i)
+		if r.Type == "SYSCALL" {
+			return NewSyscall(r), true
+		}
+	}
+	return Syscall{}, false
+}
+
+func (ctx eventContext) newInode(path Record) Inode {
+	inode := NewInode(ctx.syscall, ctx.proctitle, ctx.cwd, path)
+	inode.Sockaddr = ctx.sockPath
+	if len(ctx.execve) > 0 {
+		inode.Argv = ExecveArgs(ctx.execve)
+	}
+	inode.Mount = mountPoints[inode.Device]
+	return inode
+}
+
+// PATH records of an event in the order of operations, i.e. item=0, item=1
+// and so on. Creates must be applied before the uses that follow them in the
+// same syscall (ex. rename), so the order is load-bearing. ausearch prints
+// PATH records in reverse while audit.log has them in order, so they're
+// ordered by item=. Records w/o item= are assumed to be in ausearch order.
+func (rs Records) pathRecords() []Record {
+	var paths []Record
+	for idx := len(rs.Records) - 1; idx >= 0; idx-- {
+		if rs.Records[idx].Type == "PATH" {
+			paths = append(paths, rs.Records[idx])
 		}
 	}
 
-	// fmt.Println(syscall, proctitle)
+	item := func(r Record) int {
+		n, _ := strconv.Atoi(r.Body["item"])
+		return n
+	}
+	sort.SliceStable(paths, func(a, b int) bool {
+		return item(paths[a]) < item(paths[b])
+	})
+	return paths
+}
+
+// Generate Inodes from a set of records representing an event, in the order
+// of operations; see pathRecords.
+func (rs Records) GetInodes() *Inodes {
+	inodes := Inodes{}
+
+	ctx := rs.context()
+	for _, r := range rs.pathRecords() {
+		inodes.AddInode(ctx.newInode(r))
+	}
+
 	return &inodes
 }
 
+// Lazily generate Inodes from a set of records representing an event, in the
+// order of operations like GetInodes.
+func (rs Records) InodeSeq() iter.Seq[Inode] {
+	return func(yield func(Inode) bool) {
+		ctx := rs.context()
+		for _, r := range rs.pathRecords() {
+			if !yield(ctx.newInode(r)) {
+				return
+			}
+		}
+	}
+}
+
 /* Represents a syscall operation */
 type Syscall struct {
 	Msg     string // ID of record
@@ -345,6 +951,9 @@ type Syscall struct {
 	Number  uint64
 	Exe     string
 	Cmd     string
+	Comm    string `json:",omitempty"` // name of process (or script), see Inode.Attribution
+	Uid     string `json:",omitempty"` // numeric, or user name in interpreted logs
+	Euid    string `json:",omitempty"`
 	Pid     int64
 	Ppid    int64
 	A0      uint64
@@ -353,6 +962,9 @@ type Syscall struct {
 	A3      uint64
 	Exit    int64
 	Success bool
+	Subj    string `json:",omitempty"` // SELinux context of process
+	Tty     string // ex. pts0, or (none)
+	Ses     string // login session, 4294967295 if unset
 
 	record Record
 }
@@ -370,26 +982,52 @@ func NewSyscall(r Record) Syscall {
 		Name:   "",
 		Exe:    strings.Trim(r.Body["exe"], "\""),
 		Cmd:    strings.Trim(r.Body["cmd"], "\""),
+		Comm:   r.Body["comm"],
+		Uid:    r.Body["uid"],
+		Euid:   r.Body["euid"],
+		Subj:   r.Body["subj"],
+		Tty:    r.Body["tty"],
+		Ses:    r.Body["ses"],
 		record: r,
 	}
 
 	// add number & name
-	s.Number, _ = strconv.ParseUint(r.Body["syscall"], 10, 64)
-	if AuSyscalls != nil {
-		s.Name = AuSyscalls[fmt.Sprint(s.Number)]
+	if r.Interpreted {
+		s.Name = r.Body["syscall"]
+		s.Number = interpretedSyscall(s.Name)
+	} else {
+		s.Number, _ = strconv.ParseUint(r.Body["syscall"], 10, 64)
+		if AuSyscalls != nil {
+			s.Name = AuSyscalls[fmt.Sprint(s.Number)]
+		}
 	}
 
 	// add other metadata
 	s.Pid, _ = strconv.ParseInt(r.Body["pid"], 10, 64)
 	s.Ppid, _ = strconv.ParseInt(r.Body["ppid"], 10, 64)
+	if !r.Interpreted {
+		s.Exe = r.decodeStr(r.Body["exe"])
+		s.Comm = r.decodeStr(s.Comm)
+	}
 
-	s.A0, _ = strconv.ParseUint(r.Body["a0"], 16, 64)
-	s.A1, _ = strconv.ParseUint(r.Body["a1"], 16, 64)
-	s.A2, _ = strconv.ParseUint(r.Body["a2"], 16, 64)
-	s.A3, _ = strconv.ParseUint(r.Body["a3"], 16, 64)
+	if r.Interpreted {
+		s.A0 = interpretedArg(r.Body["a0"])
+		s.A1 = interpretedArg(r.Body["a1"])
+		s.A2 = interpretedArg(r.Body["a2"])
+		s.A3 = interpretedArg(r.Body["a3"])
+	} else {
+		s.A0, _ = strconv.ParseUint(r.Body["a0"], 16, 64)
+		s.A1, _ = strconv.ParseUint(r.Body["a1"], 16, 64)
+		s.A2, _ = strconv.ParseUint(r.Body["a2"], 16, 64)
+		s.A3, _ = strconv.ParseUint(r.Body["a3"], 16, 64)
+	}
 
 	// add exit status
-	s.Exit, _ = strconv.ParseInt(r.Body["exit"], 10, 64)
+	if r.Interpreted {
+		s.Exit = interpretedExit(r.Body["exit"])
+	} else {
+		s.Exit = parseExit(r.Body["exit"])
+	}
 	if r.Body["success"] == "yes" {
 		s.Success = true
 	} else {
@@ -399,19 +1037,94 @@ func NewSyscall(r Record) Syscall {
 	return s
 }
 
+// Marshal args a0..a3 as hex strings like in auditd logs
+func (s Syscall) MarshalJSON() ([]byte, error) {
+	type syscall Syscall // w/o MarshalJSON
+	v := struct {
+		syscall
+		A0, A1, A2, A3 string
+		Args           map[string]string `json:"syscall_args,omitempty"` // see -syscallargs
+		Errno          string            `json:",omitempty"`             // of failed syscalls, ex. EACCES
+	}{
+		syscall: syscall(s),
+		A0:      strconv.FormatUint(s.A0, 16),
+		A1:      strconv.FormatUint(s.A1, 16),
+		A2:      strconv.FormatUint(s.A2, 16),
+		A3:      strconv.FormatUint(s.A3, 16),
+	}
+	if *flagSysArgs {
+		v.Args = s.NamedArgs()
+	}
+	v.Errno, _ = s.Errno()
+	return json.Marshal(v)
+}
+
+// Unmarshal output of MarshalJSON, ex. for -merge
+func (s *Syscall) UnmarshalJSON(b []byte) error {
+	type syscall Syscall // w/o UnmarshalJSON
+	var v struct {
+		syscall
+		A0, A1, A2, A3 string
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*s = Syscall(v.syscall)
+	s.A0, _ = strconv.ParseUint(v.A0, 16, 64)
+	s.A1, _ = strconv.ParseUint(v.A1, 16, 64)
+	s.A2, _ = strconv.ParseUint(v.A2, 16, 64)
+	s.A3, _ = strconv.ParseUint(v.A3, 16, 64)
+	return nil
+}
+
 // String repr. of syscall
 func (s Syscall) String() string {
 	// if we don't have its name
 	if len(s.Name) == 0 {
-		return fmt.Sprint("syscall=", s.Number)
+		return fmt.Sprint("syscall=", s.Number) + s.status()
 	}
 
 	// for verbose print name & number
 	if *flagVerbose {
-		return fmt.Sprintf("%s(%v)", s.Name, s.Number)
+		return fmt.Sprintf("%s(%v)%s", s.Name, s.Number, s.status())
+	}
+
+	return s.Name + s.status()
+}
+
+// Annotation for failed syscalls, ex. ":fail(-13)" or ":fail(EACCES)" when
+// verbose. Empty for successful syscalls & events w/o a SYSCALL record.
+func (s Syscall) status() string {
+	if s.Success || len(s.Msg) == 0 {
+		return ""
+	}
+
+	exit := fmt.Sprint(s.Exit)
+	if *flagVerbose {
+		if name, ok := s.Errno(); ok {
+			exit = name
+		}
+	}
+	return ":fail(" + exit + ")"
+}
+
+// Did the syscall run with euid 0? False if euid is unknown.
+func (s Syscall) Privileged() (privileged, known bool) {
+	switch s.Euid {
+	case "", "unset", "4294967295":
+		return false, false
+	case "0", "root":
+		return true, true
 	}
+	return false, true
+}
 
-	return s.Name
+// Was one of the syscalls privileged and the other not?
+func privTransition(a, b *Syscall) bool {
+	aPriv, aKnown := a.Privileged()
+	bPriv, bKnown := b.Privileged()
+	return aKnown && bKnown && aPriv != bPriv
 }
 
 // For open and openat, is O_CREAT set?
@@ -421,11 +1134,11 @@ func (s Syscall) FlagCreate() bool {
 	// refer: https://marcin.juszkiewicz.com.pl/download/tables/syscalls.html
 	switch {
 	case s.Name == "open" || s.Number == 2:
-		if (s.A1 & O_CREAT) > 1 {
+		if (s.A1&O_CREAT) != 0 && s.plausibleFlags(s.A1) {
 			return true
 		}
 	case s.Name == "openat" || s.Number == 257:
-		if (s.A2 & O_CREAT) > 1 {
+		if (s.A2&O_CREAT) != 0 && s.plausibleFlags(s.A2) {
 			return true
 		}
 	case s.Name == "openat2" || s.Number == 437:
@@ -434,6 +1147,18 @@ func (s Syscall) FlagCreate() bool {
 	return false
 }
 
+// Flags are a 32-bit int, so larger values are likely pointers, ex. when the
+// args are shifted for another arch. Such flags can't be relied on.
+func (s Syscall) plausibleFlags(flags uint64) bool {
+	if flags <= math.MaxUint32 {
+		return true
+	}
+	if *flagVerbose {
+		log.Printf("flags of %s look like a pointer (%x), ignoring them", s, flags)
+	}
+	return false
+}
+
 /* Represents a path operation */
 type Inode struct {
 	Timestamp string
@@ -445,8 +1170,15 @@ type Inode struct {
 	Operation string
 	Exe       string
 	Syscall   Syscall
-	Proctitle string
+	Proctitle string   // args joined by -proctitlesep
+	PtArgs    []string `json:"ProctitleArgs,omitempty"` // args of proctitle, for raw logs
 	Cwd       string
+	Sockaddr  string   `json:",omitempty"` // path of AF_UNIX socket, if any
+	Mount     string   `json:",omitempty"` // mount point of Device, see -mountinfo
+	Obj       string   `json:",omitempty"` // SELinux context of inode
+	Argv      []string `json:",omitempty"` // arguments of execve, from EXECVE records
+	Boot      int      `json:",omitempty"` // boot of its host, see Timeline.Boot
+	Gen       string   `json:",omitempty"` // inode generation, if logged
 }
 
 func NewInode(syscall, proctitle, cwd, path Record) Inode {
@@ -459,47 +1191,136 @@ func NewInode(syscall, proctitle, cwd, path Record) Inode {
 		Mode:      0,
 		Operation: path.Body["nametype"],
 		Exe:       syscall.Body["exe"],
-		Syscall:   NewSyscall(syscall),
 		Proctitle: proctitle.Body["proctitle"],
 		Cwd:       cwd.Body["cwd"],
+		Obj:       path.Body["obj"],
+		Gen:       inodeGeneration(path),
+	}
+
+	// some events (ex. AVC) have PATH records without a SYSCALL
+	if syscall.Type == "SYSCALL" {
+		i.Syscall = NewSyscall(syscall)
 	}
 
 	// Post-process relevant fields
-	mode, _ := strconv.Atoi(path.Body["mode"])
+	rawMode := path.Body["mode"]
+	if path.Interpreted {
+		rawMode = interpretedMode(rawMode)
+	}
+	mode, _ := strconv.ParseUint(rawMode, 8, 16) // ex. 040775
 	i.Mode = uint16(mode)
 
-	i.Path = strings.Trim(i.Path, "\"")
-
 	i.Exe = strings.Trim(i.Exe, "\"")
+	if !syscall.Interpreted {
+		i.Exe = syscall.decodeStr(syscall.Body["exe"])
+	}
+
+	// interpreted logs have decoded strings
+	if path.Interpreted {
+		return i
+	}
+
+	i.Path = path.decodeStr(i.Path)
 
 	decodedBytes, err := hex.DecodeString(i.Proctitle)
 	if err != nil {
 		log.Printf("%v; cannot decode proctitle for %v\n", err, i)
 	} else {
-		// replace nulls with space in string
-		charNull := make([]byte, 1)
-		charSpace := make([]byte, 1)
+		// args are separated by nulls; there are none w/o PROCTITLE
+		if len(decodedBytes) > 0 {
+			i.PtArgs = strings.Split(string(decodedBytes), "\x00")
+		}
 
+		// replace nulls with separator (space by default) in string
+		charNull := make([]byte, 1)
 		utf8.EncodeRune(charNull, '\u0000')
-		utf8.EncodeRune(charSpace, ' ')
-		withSpaces := bytes.ReplaceAll(decodedBytes, charNull, charSpace)
+		withSpaces := bytes.ReplaceAll(decodedBytes, charNull, []byte(*flagPtSep))
 
 		// string recovered
 		i.Proctitle = string(withSpaces)
 	}
 
 	// process valid cwd entry
-	i.Cwd = strings.Trim(i.Cwd, "\"")
+	i.Cwd = cwd.decodeStr(i.Cwd)
 
 	return i
 }
 
+// Decode untrusted strings (like name & cwd) as logged by auditd. They're
+// quoted, unless they contain special characters (ex. space), in which case
+// they're hex-encoded without quotes.
+func decodeAuditStr(s string) string {
+	if strings.HasPrefix(s, "\"") {
+		return strings.Trim(s, "\"")
+	}
+
+	if s == "(null)" {
+		return s
+	}
+
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return s // not encoded
+	}
+	return string(decoded)
+}
+
+// Decode an untrusted string of the record, see decodeAuditStr. Verbatim
+// strings only have their quotes dropped, as they may look like hex, ex. cafe.
+func (r Record) decodeStr(s string) string {
+	if r.Verbatim {
+		return strings.Trim(s, "\"")
+	}
+	return decodeAuditStr(s)
+}
+
+// Name of inodes without a device or inode#, or logged as "?" for missing
+// targets. They can't be told apart, so they're never correlated.
+const NoName = "(none)"
+
 // Get unique name for an Inode. It's unique for a given OS.
 func (i Inode) Name() string {
+	if len(i.Device) == 0 || len(i.InodeNum) == 0 || i.Device == "?" || i.InodeNum == "?" {
+		return NoName
+	}
 	name := i.Device + "|" + i.InodeNum
+	if len(i.Gen) != 0 {
+		// tells apart reuses of the inode number
+		name += "|" + i.Gen
+	}
 	return name
 }
 
+// Keys of the inode generation in PATH records, when logged
+var generationKeys = []string{"igen", "generation", "gen"}
+
+func inodeGeneration(path Record) string {
+	for _, key := range generationKeys {
+		if gen, ok := path.Body[key]; ok && gen != "?" {
+			return gen
+		}
+	}
+	return ""
+}
+
+// Time of the syscall, parsed from msg
+func (i Inode) Time() (time.Time, bool) {
+	return parseMsgTime(i.Msg)
+}
+
+// Time elapsed from i until later
+func (i Inode) Elapsed(later *Inode) (time.Duration, bool) {
+	start, ok := i.Time()
+	if !ok {
+		return 0, false
+	}
+	end, ok := later.Time()
+	if !ok {
+		return 0, false
+	}
+	return end.Sub(start), true
+}
+
 // String repr. for printing on console
 func (i Inode) String() string {
 	// absolute or relative path
@@ -510,28 +1331,66 @@ func (i Inode) String() string {
 		p = i.Path
 	}
 
-	// verbose mode
-	var msg string
-	if *flagVerbose {
-		msg = i.Msg
-	} else {
-		// example: i.Msg = audit(1628098489.574:15451)
-		strArr := strings.Split(i.Msg, ":")
-		str := strArr[len(strArr)-1] // "15451)"
-		str = strings.Trim(str, ")") // "15451"
-		msg = "msg=" + str + ","     // "msg=15451,"
-	}
+	msg := i.msgLabel()
 
 	// example of string repr.:
 	// [audit(1628098489.574:15451)'git'.unlink(87)]00:39|2123|a/
+	// show mount point instead of device, if known
+	name := i.Name()
+	if len(i.Mount) > 0 {
+		name = i.Mount + "|" + i.InodeNum
+	}
+
 	str := fmt.Sprintf("[%v'%v'.%v]%v|%s",
-		msg, path.Base(i.Exe), i.Syscall, i.Name(), p)
+		msg, i.Attribution(), i.Syscall, name, p)
+
+	if *flagVerbose && len(i.Sockaddr) > 0 {
+		str += "(saddr=" + i.Sockaddr + ")"
+	}
+	if *flagVerbose && len(i.Obj) > 0 {
+		str += "(obj=" + i.Obj + ")"
+	}
+
+	// full command when verbose, else truncated if requested
+	if *flagVerbose && len(i.Proctitle) > 0 {
+		str += "(proctitle=" + i.Proctitle + ")"
+	} else if *flagMaxPTitle > 0 && len(i.Proctitle) > 0 {
+		str += "(proctitle=" + truncate(i.Proctitle, *flagMaxPTitle) + ")"
+	}
 
 	return str
 }
 
+// Record ID as shown in output, in full when verbose
+func (i Inode) msgLabel() string {
+	if *flagVerbose {
+		return i.Msg
+	}
+
+	// example: i.Msg = audit(1628098489.574:15451)
+	strArr := strings.Split(i.Msg, ":")
+	str := strArr[len(strArr)-1] // "15451)"
+	str = strings.Trim(str, ")") // "15451"
+	return "msg=" + str + ","    // "msg=15451,"
+}
+
+// Shorten s to n characters, marking truncation with an ellipsis
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
+
 // Convert relative paths to absolute paths using "cwd".
+//
+// With -nocwdresolve, paths are kept as logged, ex. when cwd is untrusted.
 func (i Inode) getAbsPath() string {
+	if *flagNoCwd {
+		return i.Path
+	}
+
 	// ensure paths aren't empty
 	if len(strings.Trim(i.Cwd, " ")) == 0 ||
 		len(strings.Trim(i.Path, " ")) == 0 {
@@ -547,13 +1406,37 @@ func (i Inode) getAbsPath() string {
 	return path.Join(i.Cwd, i.Path)
 }
 
+// Is the path relative, but there's no cwd to resolve it against? This
+// happens for events without a CWD record.
+func (i Inode) MissingCwd() bool {
+	if *flagNoCwd || len(i.Path) == 0 || i.Path[0] == '/' || i.Path == "(null)" {
+		return false
+	}
+	return len(strings.Trim(i.Cwd, " ")) == 0
+}
+
+// Why the resolved path of a relative path is uncertain; empty if it's
+// confident. Relative paths are resolved against cwd, which is impossible
+// w/o a CWD record and wrong for *at syscalls given a dirfd.
+func (i Inode) Uncertainty() string {
+	switch {
+	case len(i.Path) == 0 || i.Path[0] == '/' || i.Path == "(null)":
+		return ""
+	case *flagNoCwd:
+		return "relative path not resolved (-nocwdresolve)"
+	case i.MissingCwd():
+		return "cwd unavailable"
+	case i.Syscall.UsesDirfd():
+		return "resolved against cwd instead of dirfd"
+	}
+	return ""
+}
+
 // Is it directory or file (regular, pipe, etc.)?
 func (i Inode) IsDir() bool {
 	// See stat.st_mode (in man 7 inode)
-	if (i.Mode & 40000) > 1 {
-		return true
-	}
-	return false
+	const S_IFMT, S_IFDIR = 0170000, 0040000
+	return i.Mode&S_IFMT == S_IFDIR
 }
 
 // Remove trailing "/" only if directory. We don't touch symbolic links.
@@ -565,6 +1448,15 @@ func (i Inode) NormalizedPath() string {
 	return p
 }
 
+// Remove trailing "/" irrespective of file type, but keep "/"
+func trimTrailingSlash(p string) string {
+	trimmed := strings.TrimRight(p, "/")
+	if len(trimmed) == 0 && len(p) > 0 {
+		return "/"
+	}
+	return trimmed
+}
+
 // Holds collection of Inodes
 type Inodes []Inode
 
@@ -573,64 +1465,339 @@ func (ins *Inodes) AddInode(i Inode) {
 }
 
 // Report of create-use pairs
-type Report struct{ Create, Use *Inode }
+type Report struct {
+	Category    Category
+	Kind        ReportKind `json:",omitempty"`
+	Create, Use *Inode
+	Explanation string   `json:",omitempty"` // set by -explain
+	Delta       string   `json:",omitempty"` // time elapsed from create to use
+	Notes       []string `json:",omitempty"` // caveats, ex. missing cwd
+	Host        string   `json:",omitempty"` // label from -file host=logfile
+	Config      string   `json:",omitempty"` // blinding audit config change, see -watchrules
+	Hardlink    bool     `json:",omitempty"` // paths are known hardlinks of the inode
+	PrivChange  bool     `json:",omitempty"` // one of create & use was privileged (euid 0)
+	Ancestry    []string `json:",omitempty"` // exes of the use's ancestors, see -ancestry
+	Chain       []Inode  `json:",omitempty"` // operations on the inode up to the use, see -chain
+	AVCs        []AVC    `json:",omitempty"` // SELinux/AppArmor decisions about the create or use
+	Source      string   `json:",omitempty"` // json output the report was read from, see -merge
+	Count       int      `json:",omitempty"` // findings coalesced into this one, see -groupwindow
+	Until       string   `json:",omitempty"` // time of the last coalesced finding, RFC 3339
+}
 
 // Play FS operations against a timeline
 type Timeline struct {
-	history map[string]Inode
-	reports []Report
+	history  map[string]Inode
+	used     map[string]bool // creates in history that were used since
+	reports  []Report
+	onReport func(Report) // replaces printing/collecting when set
+	onEvent  func(time.Time)
+	equal    Comparator
+	rules    []Rule  // checked for each create-use pair
+	fds      FdTable // files opened per process, see -trackfds
+	matches  int     // create-use pairs that weren't reported
+	paired   int     // create-use pairs found
+	unsure   int     // of them, pairs with an uncertain path
+	links    LinkTable
+	checks   CheckTable
+	procs    ProcTree
+	dirs     DirTable
+	chains   ChainTable
+	order    historyOrder
+	chkOrder historyOrder // of checks, to evict them like history
+	chained  []string     // inodes added to chains in the event being applied
+	nodes    map[string]*Timeline
+	avcs     []AVC    // recent AVCs, see AVCsAbout
+	last     eventPos // last applied event, see detectBoot
+
+	Host string // label of host whose logs are applied
+	Boot int    // reboots seen in logs applied so far
 }
 
 func NewTimeline() Timeline {
-	tm := Timeline{history: make(map[string]Inode)}
+	tm := Timeline{
+		history:  make(map[string]Inode),
+		used:     make(map[string]bool),
+		fds:      make(FdTable),
+		checks:   make(CheckTable),
+		procs:    make(ProcTree),
+		dirs:     make(DirTable),
+		chains:   make(ChainTable),
+		order:    newHistoryOrder(),
+		chkOrder: newHistoryOrder(),
+		links:    make(LinkTable),
+		equal:    comparators[*flagCompare],
+	}
+	tm.AddRule(PathRule{Equal: tm.equal})
+	if *flagSELinux {
+		tm.AddRule(ContextRule{})
+	}
 	return tm
 }
 
-func (tm *Timeline) Report(create, use *Inode) {
-	if *flagJson {
-		tm.ReportLater(create, use)
+// Compare a create-use pair for the same inode, see comparePaths
+func (tm *Timeline) compare(create, use *Inode) Category {
+	return comparePaths(tm.equal, create, use)
+}
+
+// Build report of a create-use pair, along with its annotations
+func NewReport(category Category, create, use *Inode) Report {
+	r := Report{Category: category, Create: create, Use: use}
+	r.PrivChange = privTransition(&create.Syscall, &use.Syscall)
+	if delta, ok := create.Elapsed(use); ok {
+		r.Delta = delta.String()
+	}
+	if *flagExplain {
+		r.Explanation = explain(category, create, use)
+	}
+	if why := create.Uncertainty(); len(why) > 0 {
+		r.Notes = append(r.Notes, why+" for create path")
+	}
+	if why := use.Uncertainty(); len(why) > 0 {
+		r.Notes = append(r.Notes, why+" for use path")
+	}
+	return r
+}
+
+// Call fn for each violation as soon as it's found, instead of printing or
+// collecting it.
+func (tm *Timeline) OnReport(fn func(Report)) {
+	tm.onReport = fn
+}
+
+// Call fn with the time of each event after applying it, ex. to pass on
+// findings held for -groupwindow
+func (tm *Timeline) OnEvent(fn func(time.Time)) {
+	tm.onEvent = fn
+}
+
+// Filter, annotate & redact a violation found in this timeline, then Emit
+// it. Filters see the raw paths, as redaction comes last.
+func (tm *Timeline) Report(r Report) {
+	if len(r.Host) == 0 {
+		r.Host = tm.Host
+	}
+
+	if r.Kind == KindPair { /* config changes are always reported as is */
+		if *flagPrivTrans && !r.PrivChange {
+			return
+		}
+
+		if allowed.Match(r) || !keepPaths(r) {
+			return
+		}
+
+		if *flagAncestry {
+			r.Ancestry = tm.procs.Ancestry(r.Use.Syscall)
+		}
+		if *flagChain {
+			r.Chain = tm.chains.Of(r)
+		}
+		r.AVCs = tm.AVCsAbout(r)
+	}
+
+	if *flagRedact {
+		r = redactReport(r)
+	}
+	tm.Emit(r)
+}
+
+// Pass on, print or collect a violation that was already filtered &
+// annotated, ex. by a host's timeline. See Report.
+func (tm *Timeline) Emit(r Report) {
+	if tm.onReport != nil {
+		tm.onReport(r)
+		return
+	}
+
+	// sorting & grouping need all violations first, as does a json array
+	jsonArray := *flagJson && *flagJSONMode != "ndjson"
+	if jsonArray || *flagSort != "chrono" || len(*flagGroupBy) > 0 || len(*flagSummary) > 0 {
+		tm.ReportLater(r)
 	} else {
-		tm.ReportImmediatly(create, use)
+		tm.ReportImmediatly(r)
 	}
 }
 
 // Immediately report violations
-func (tm Timeline) ReportImmediatly(create, use *Inode) {
-	fmt.Printf("USE%v CREATE%v\n", use, create)
+func (tm Timeline) ReportImmediatly(r Report) {
+	if *flagGHAnnot {
+		printAnnotation(r)
+		return
+	}
+
+	if *flagJson { /* -jsonmode ndjson */
+		printJSON([]Report{r}, "ndjson")
+		return
+	}
+
+	if len(outputFields) > 0 {
+		printFields(r, outputFields)
+		return
+	}
+
+	if *flagPathsOnly {
+		if r.Kind == KindPair {
+			fmt.Printf("%s\t%s\n", r.Create.NormalizedPath(), r.Use.NormalizedPath())
+		}
+		return
+	}
+
+	if len(r.Source) > 0 {
+		fmt.Printf("source=%s ", r.Source)
+	}
+	if len(r.Host) > 0 {
+		fmt.Printf("host=%s ", r.Host)
+	}
+	if r.Kind == KindConfig {
+		fmt.Printf("%s[%s] %s\n", r.useLabel(), strings.TrimSuffix(r.Use.msgLabel(), ","), r.Config)
+		return
+	}
+	if *flagVerbose {
+		fmt.Printf("%s%v %s%v category=%s delta=%s time=%s tty=%s ses=%s\n",
+			r.useLabel(), r.Use, r.createLabel(), r.Create, r.Category, r.Delta, r.Use.TimeLabel(),
+			r.Use.Syscall.Tty, r.Use.Syscall.Ses)
+	} else {
+		fmt.Printf("%s%v %s%v\n", r.useLabel(), r.Use, r.createLabel(), r.Create)
+	}
+	if len(r.Explanation) > 0 {
+		fmt.Printf("\twhy: %s\n", r.Explanation)
+	}
+	if len(r.Ancestry) > 0 {
+		fmt.Printf("\tancestry: %s\n", strings.Join(r.Ancestry, " -> "))
+	}
+	if len(r.Chain) > 0 {
+		printChain(r.Chain)
+	}
+	if r.Count > 0 {
+		fmt.Printf("\tburst: %d findings until %s\n", r.Count, untilLabel(r.Until))
+	}
+	if *flagDiffPaths {
+		if diff := reportPathDiff(r); len(diff) > 0 {
+			fmt.Printf("\tdiff: %s\n", diff)
+		}
+	}
+	for _, a := range r.AVCs {
+		fmt.Printf("\tavc: %v\n", a)
+	}
+	for _, note := range r.Notes {
+		fmt.Printf("\tnote: %s\n", note)
+	}
 }
 
 // Collect all violations for reporting later
-func (tm *Timeline) ReportLater(create, use *Inode) {
-	r := Report{create, use}
+func (tm *Timeline) ReportLater(r Report) {
 	tm.reports = append(tm.reports, r)
 }
 
-// Output all collected violations
-func (tm Timeline) processPendingRepots(pretty bool) {
-	if len(tm.reports) == 0 {
+// Output all collected violations. A json array is output even if there are
+// none, unless violations are passed on, see OnReport.
+func (tm Timeline) processPendingRepots() {
+	if len(tm.reports) == 0 && (!*flagJson || tm.onReport != nil) {
 		return
 	}
 
-	var result []byte
-	if pretty {
-		result, _ = json.MarshalIndent(tm.reports, "", "  ")
-	} else {
-		result, _ = json.Marshal(tm.reports)
+	sortReports(tm.reports, *flagSort)
+
+	if *flagSummary == "paths" {
+		counts := countPaths(tm.reports)
+		if !*flagJson {
+			printPathCounts(counts)
+			return
+		}
+		printJSON(counts, *flagJSONMode)
+		return
+	}
+
+	if *flagGHAnnot {
+		for _, r := range tm.reports {
+			printAnnotation(r)
+		}
+		return
+	}
+
+	if len(*flagGroupBy) > 0 && !*flagPathsOnly && len(outputFields) == 0 {
+		groups := groupReports(tm.reports, *flagGroupBy)
+		if !*flagJson {
+			printGroups(groups, *flagGroupBy)
+			return
+		}
+		printJSON(groups, *flagJSONMode)
+		return
 	}
 
-	fmt.Println(string(result))
+	if !*flagJson {
+		for _, r := range tm.reports {
+			tm.ReportImmediatly(r)
+		}
+		return
+	}
+
+	printJSON(tm.reports, *flagJSONMode)
 }
 
 func (tm *Timeline) Close() {
-	tm.processPendingRepots(*flagPretty)
+	for _, t := range tm.nodes {
+		t.Close() // reports were passed to tm
+	}
+	tm.processPendingRepots()
+	if *flagShowMatch {
+		log.Printf("%d create-use pairs matched w/o violations", tm.matches)
+	}
+	if tm.paired > 0 {
+		log.Printf("%d of %d create-use pairs (%.0f%%) relied on uncertain path resolution",
+			tm.unsure, tm.paired, 100*float64(tm.unsure)/float64(tm.paired))
+	}
+	if *flagVerbose && len(tm.history) > 0 || evicting() && tm.order.seq > 0 {
+		tm.logHistoryStats()
+	}
+	if *flagDumpHist {
+		tm.dumpHistory()
+	}
+}
+
+// Print creates recorded in history, keyed by inode name. Shows why an
+// expected violation wasn't reported.
+func (tm Timeline) dumpHistory() {
+	dump := struct {
+		Host    string `json:",omitempty"`
+		History map[string]Inode
+	}{tm.Host, tm.history}
+
+	result, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		log.Printf("cannot dump history: %v", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(result))
 }
 
 // Apply a single inode against the timeline
 func (tm *Timeline) Apply(i *Inode) {
+	i.Boot = tm.Boot
+	if ignored.Match(i) || i.IsAnonymous() {
+		return
+	}
+
+	if !onlySyscalls.Empty() && !onlySyscalls.Contains(i.Syscall) {
+		return
+	}
+
 	name := i.Name()
+	if name == NoName {
+		if *flagVerbose {
+			log.Printf("no device or inode#, skipping: %v", i)
+		}
+		return
+	}
+
+	if *flagChain && (i.Syscall.Success || *flagInclFailed) {
+		tm.chains.Add(i)
+		tm.chained = append(tm.chained, name)
+	}
+
 	recordCreate := func() {
 		// ignore failed syscall
-		if !i.Syscall.Success {
+		if !i.Syscall.Success && !*flagInclFailed {
 			return
 		}
 
@@ -638,12 +1805,20 @@ func (tm *Timeline) Apply(i *Inode) {
 			/* syscall operates on inode# */
 			return
 		}
+
+		// Created again under another name, but never used?
+		if prev, ok := tm.history[name]; ok && *flagReCreate && !tm.used[name] {
+			if tm.compare(&prev, i) != CategoryNone {
+				tm.Report(NewReport(CategoryRecreate, &prev, i))
+			}
+		}
+
 		// Record create
-		tm.history[name] = *i
+		tm.recordHistory(name, i)
 	}
 	verifyUse := func() {
 		// ignore failed syscall
-		if !i.Syscall.Success {
+		if !i.Syscall.Success && !*flagInclFailed {
 			return
 		}
 
@@ -651,8 +1826,16 @@ func (tm *Timeline) Apply(i *Inode) {
 			log.Printf("use with O_CREAT: %v", i)
 		}
 
-		if i.Path == "(null)" {
-			/* syscall operates on inode# */
+		if i.Path == "(null)" || len(i.Path) == 0 {
+			/* syscall operates on inode#, ex. with AT_EMPTY_PATH */
+			return
+		}
+
+		// O_PATH opens don't access the file, so they aren't a use
+		if flags, ok := i.Syscall.OpenFlags(); ok && flags&O_PATH != 0 {
+			if *flagVerbose {
+				log.Printf("open with O_PATH, skipping: %v", i)
+			}
 			return
 		}
 
@@ -662,6 +1845,8 @@ func (tm *Timeline) Apply(i *Inode) {
 		if create, ok = tm.history[name]; !ok {
 			return // no corresponding CREATE
 		}
+		tm.used[name] = true
+		tm.touchHistory(name, i)
 
 		// Log violations within process boundary
 		if *flagSamePID {
@@ -677,39 +1862,141 @@ func (tm *Timeline) Apply(i *Inode) {
 			}
 		}
 
+		tm.paired++
+		if len(create.Uncertainty()) > 0 || len(i.Uncertainty()) > 0 {
+			tm.unsure++
+		}
+
 		// Test for inconsistency
-		cPATH := create.NormalizedPath()
-		uPATH := i.NormalizedPath()
-		if cPATH != uPATH {
-			tm.Report(&create, i)
+		reported := false
+		hardlink := tm.isHardlink(&create, i)
+		for _, rule := range tm.rules {
+			r, ok := rule.Check(&create, i)
+			if !ok {
+				continue
+			}
+			if hardlink {
+				if *flagNoHardlink {
+					continue
+				}
+				r.Hardlink = true
+				r.Notes = append(r.Notes, "paths are hardlinks made by link()")
+			}
+			tm.Report(r)
+			reported = true
+		}
+
+		if !reported {
+			tm.matches++
+			if *flagShowMatch {
+				log.Printf("match: USE%v CREATE%v", i, &create)
+			}
 		}
 	}
 
-	switch i.Operation {
-	case "CREATE":
+	op, ok := nametypeOps[i.Operation]
+	switch {
+	case op == OpCreate:
 		recordCreate()
-	case "PARENT":
-		fallthrough
-	case "NORMAL":
+	case op == OpUse:
 		verifyUse()
-	case "DELETE":
-		delete(tm.history, name)
-	case "UNKNOWN":
+	case op == OpDelete:
+		tm.deleteHistory(name)
+		tm.removeLink(i)
+	case op == OpIgnore:
 		if *flagVerbose {
-			log.Printf("op=UNKNOWN: %v", i)
+			log.Printf("op=%s: %v", i.Operation, i)
+		}
+	case !ok:
+		/* newer kernels may add nametypes */
+		if *flagVerbose {
+			log.Printf("unhandled op=%s, skipping: %v", i.Operation, i)
 		}
-	default:
-		/* code */
-		log.Fatal("Unhandled PATH operation: ", i.Operation)
 	}
 }
 
-// Apply set of inodes against a timeline.
-//
-// We apply in reverse order to preserve order of operations, i.e. apply item=0,
-// item=1 and so on.
+// Apply inodes in the order they're generated, ex. by Records.InodeSeq
+func (tm *Timeline) ApplySeq(inodes iter.Seq[Inode]) {
+	for i := range inodes {
+		tm.Apply(&i)
+	}
+}
+
+// Apply the records of an event against the timeline
+func (tm *Timeline) ApplyRecords(rs *Records) {
+	if node := rs.Node(); *flagNodeHost && len(node) > 0 && node != tm.Host {
+		tm.nodeTimeline(node).ApplyRecords(rs)
+		return
+	}
+	tm.detectBoot(rs)
+	if *flagOnlyCreates || *flagOnlyUses {
+		dumpInodes(rs)
+		return
+	}
+	if *flagVerbose {
+		rs.logUnknown()
+	}
+	if *flagTraceEvent && len(rs.Records) > 0 {
+		log.Println(rs.summary())
+	}
+	if *flagWatchRules {
+		tm.watchRules(rs)
+	}
+	tm.trackAVCs(rs)
+	tm.trackLinks(rs)
+	if *flagTrackFds {
+		tm.trackFds(rs)
+	}
+	if *flagWriteCheck {
+		tm.trackChecks(rs)
+	}
+	if *flagAncestry {
+		tm.trackProcs(rs)
+	}
+	if *flagParentDirs {
+		tm.trackParents(rs)
+	}
+	tm.ApplySeq(rs.InodeSeq())
+	tm.pruneChains()
+
+	if tm.onEvent != nil && len(rs.Records) > 0 {
+		if at, ok := parseMsgTime(rs.Records[0].Msg); ok {
+			tm.onEvent(at)
+		}
+	}
+}
+
+// Apply a single raw event, i.e. the lines of one auditd event. Violations are
+// returned instead of being printed or collected, but are still passed to the
+// OnReport callback.
+func (tm *Timeline) ApplyEvent(raw string) []Report {
+	var found []Report
+
+	onReport := tm.onReport
+	defer func() { tm.onReport = onReport }()
+	tm.onReport = func(r Report) {
+		found = append(found, r)
+		if onReport != nil {
+			onReport(r)
+		}
+	}
+
+	rs := &Records{}
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSuffix(line, "\r") // CRLF line endings
+		if line != AuditdSep {
+			rs.AddLine(line)
+		}
+	}
+	tm.ApplyRecords(rs)
+
+	return found
+}
+
+// Apply set of inodes against a timeline, in order. Inodes from GetInodes are
+// already in the order of operations.
 func (tm *Timeline) ApplyInodes(inodes *Inodes) {
-	for i := len(*inodes) - 1; i >= 0; i-- {
+	for i := range *inodes {
 		tm.Apply(&(*inodes)[i])
 	}
 }
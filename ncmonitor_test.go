@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Raw auditd event with a SYSCALL of fields (after arch=), a CWD unless cwd
+// is empty, and PATH records of fields (after item=N)
+func rawEvent(serial int, fields, cwd string, paths ...string) string {
+	msg := fmt.Sprintf("audit(1626882755.%03d:%d)", serial, serial)
+	lines := []string{fmt.Sprintf("type=SYSCALL msg=%s: arch=c000003e %s", msg, fields)}
+	if len(cwd) > 0 {
+		lines = append(lines, fmt.Sprintf("type=CWD msg=%s: cwd=\"%s\"", msg, cwd))
+	}
+	for n, p := range paths {
+		lines = append(lines, fmt.Sprintf("type=PATH msg=%s: item=%d %s", msg, n, p))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// openat(O_WRONLY|O_CREAT|O_TRUNC) of name by /usr/bin/creator
+func createEvent(serial int, name, inode string) string {
+	return rawEvent(serial,
+		`syscall=257 success=yes exit=3 a0=ffffff9c a1=7ffd a2=241 a3=1b6 items=2 ppid=1 pid=100 auid=1000 uid=0 gid=0 euid=0 comm="creator" exe="/usr/bin/creator"`,
+		"/",
+		`name="/tmp/" inode=2 dev=08:03 mode=040777 ouid=0 ogid=0 rdev=00:00 nametype=PARENT`,
+		fmt.Sprintf(`name="%s" inode=%s dev=08:03 mode=0100644 ouid=0 ogid=0 rdev=00:00 nametype=CREATE`, name, inode))
+}
+
+// openat(O_RDONLY) of name by /usr/bin/user
+func useEvent(serial int, name, inode string) string {
+	return rawEvent(serial,
+		`syscall=257 success=yes exit=3 a0=ffffff9c a1=7ffd a2=0 a3=0 items=1 ppid=1 pid=200 auid=1000 uid=0 gid=0 euid=0 comm="user" exe="/usr/bin/user"`,
+		"/",
+		fmt.Sprintf(`name="%s" inode=%s dev=08:03 mode=0100644 ouid=0 ogid=0 rdev=00:00 nametype=NORMAL`, name, inode))
+}
+
+// Events separated by "----", w/o a trailing separator
+func rawLog(events ...string) string {
+	return AuditdSep + "\n" + strings.Join(events, "\n"+AuditdSep+"\n")
+}
+
+// Violations found by parsing a raw log with parse, & the timeline it was
+// applied to
+func reportsOf(parse func(*Timeline, []byte), log string) ([]Report, *Timeline) {
+	var reports []Report
+	tm := NewTimeline()
+	tm.OnReport(func(r Report) {
+		reports = append(reports, r)
+	})
+	parse(&tm, []byte(log))
+	return reports, &tm
+}
+
+func TestLastEventWithoutSeparator(t *testing.T) {
+	log := rawLog(createEvent(1, "/tmp/a", "10"), useEvent(2, "/tmp/A", "10"))
+	if strings.HasSuffix(log, AuditdSep) {
+		t.Fatal("log must not end with a separator")
+	}
+
+	reports, _ := reportsOf(ParseLogContent, log)
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1 for the last event", len(reports))
+	}
+	if r := reports[0]; r.Category != CategoryCaseMismatch || r.Use.Path != "/tmp/A" {
+		t.Errorf("got %s of %s, want case-mismatch of /tmp/A", r.Category, r.Use.Path)
+	}
+}
+
+func TestDecodeAuditStr(t *testing.T) {
+	tests := []struct {
+		logged, want string
+	}{
+		{`"/tmp/a"`, "/tmp/a"},           // quoted
+		{`"cafe"`, "cafe"},               // quoted, looks like hex
+		{"2F746D702F612062", "/tmp/a b"}, // hex, has a space
+		{"2f746d702f612062", "/tmp/a b"}, // hex, lowercase
+		{"(null)", "(null)"},             // no name
+		{"abc", "abc"},                   // not hex, odd length
+		{"/tmp/x", "/tmp/x"},             // not hex
+		{"", ""},                         // empty
+	}
+	for _, tt := range tests {
+		if got := decodeAuditStr(tt.logged); got != tt.want {
+			t.Errorf("decodeAuditStr(%q) = %q, want %q", tt.logged, got, tt.want)
+		}
+	}
+}
+
+func TestHexEncodedName(t *testing.T) {
+	// create is logged hex-encoded, use quoted
+	log := rawLog(createEvent(1, "/tmp/a b", "10"), useEvent(2, "/tmp/a b", "10"))
+	log = strings.Replace(log, `name="/tmp/a b"`, "name=2F746D702F612062", 1)
+
+	if reports, _ := reportsOf(ParseLogContent, log); len(reports) != 0 {
+		t.Errorf("got %s of %q & %q, want none as both names are /tmp/a b",
+			reports[0].Category, reports[0].Create.Path, reports[0].Use.Path)
+	}
+}
+
+func TestFlagCreate(t *testing.T) {
+	tests := []struct {
+		s    Syscall
+		want bool
+	}{
+		{Syscall{Name: "open", A1: 0100}, true},              // only O_CREAT
+		{Syscall{Name: "openat", A2: 0100}, true},            // only O_CREAT
+		{Syscall{Number: 257, A2: 0100}, true},               // w/o name
+		{Syscall{Name: "openat", A2: 01101}, true},           // O_WRONLY|O_CREAT|O_TRUNC
+		{Syscall{Name: "openat", A2: 01}, false},             // O_WRONLY
+		{Syscall{Name: "openat", A1: 0100}, false},           // flags are a2
+		{Syscall{Name: "openat", A2: 0x7ffd00000040}, false}, // pointer, see plausibleFlags
+		{Syscall{Name: "read", A1: 0100}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.s.FlagCreate(); got != tt.want {
+			t.Errorf("FlagCreate() of %s a1=%o a2=%o = %v, want %v", tt.s.Name, tt.s.A1, tt.s.A2, got, tt.want)
+		}
+	}
+}
+
+func TestEventWithoutCwd(t *testing.T) {
+	use := rawEvent(2,
+		`syscall=257 success=yes exit=3 a0=ffffff9c a1=7ffd a2=0 a3=0 items=1 ppid=1 pid=200 auid=1000 uid=0 gid=0 euid=0 comm="user" exe="/usr/bin/user"`,
+		"", /* no CWD record */
+		`name="A" inode=10 dev=08:03 mode=0100644 ouid=0 ogid=0 rdev=00:00 nametype=NORMAL`)
+	if strings.Contains(use, "type=CWD") {
+		t.Fatal("use event must not have a CWD record")
+	}
+
+	reports, _ := reportsOf(ParseLogContent, rawLog(createEvent(1, "/tmp/a", "10"), use))
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	r := reports[0]
+	if got := r.Use.NormalizedPath(); got != "A" {
+		t.Errorf("use path resolved to %q, want it left as A", got)
+	}
+	found := false
+	for _, note := range r.Notes {
+		found = found || strings.Contains(note, "cwd unavailable")
+	}
+	if !found {
+		t.Errorf("notes %q don't say cwd was unavailable", r.Notes)
+	}
+}
+
+func TestCRLFLog(t *testing.T) {
+	lf := rawLog(createEvent(1, "/tmp/a", "10"), useEvent(2, "/tmp/A", "10")) + "\n" + AuditdSep + "\n"
+	crlf := strings.ReplaceAll(lf, "\n", "\r\n")
+
+	want, _ := reportsOf(ParseLogContent, lf)
+	got, _ := reportsOf(ParseLogContent, crlf)
+	if len(want) != 1 || len(got) != len(want) {
+		t.Fatalf("got %d reports for CRLF, %d for LF; want 1 each", len(got), len(want))
+	}
+	if got[0].Category != want[0].Category || got[0].Use.Path != want[0].Use.Path ||
+		got[0].Create.Path != want[0].Create.Path || got[0].Use.Exe != want[0].Use.Exe {
+		t.Errorf("CRLF report %s of %q (%q) differs from LF report %s of %q (%q)",
+			got[0].Category, got[0].Use.Path, got[0].Use.Exe,
+			want[0].Category, want[0].Use.Path, want[0].Use.Exe)
+	}
+}
+
+// Events w/o a SYSCALL record, ex. of AVCs, used to hit log.Fatalf
+func TestEventWithoutSyscall(t *testing.T) {
+	msg := "audit(1626882755.002:2)"
+	avc := strings.Join([]string{
+		`type=AVC msg=` + msg + `: avc:  denied  { read } for  pid=200 comm="user" name="A" dev="sda3" ino=10 scontext=u:r:a:s0 tcontext=u:object_r:b:s0 tclass=file permissive=0`,
+		`type=PATH msg=` + msg + `: item=0 name="/tmp/A" inode=10 dev=08:03 mode=0100644 ouid=0 ogid=0 rdev=00:00 nametype=NORMAL`,
+	}, "\n")
+
+	var rs Records
+	for _, line := range strings.Split(avc, "\n") {
+		rs.AddLine(line)
+	}
+	n := 0
+	for i := range rs.InodeSeq() {
+		n++
+		if s := i.Syscall; len(s.Msg) > 0 || s.Number != 0 || s.Pid != 0 {
+			t.Errorf("got syscall %v for an event w/o SYSCALL, want a zero Syscall", s)
+		}
+	}
+	if n != 1 {
+		t.Errorf("got %d inodes, want the PATH's", n)
+	}
+
+	// applying it mustn't exit
+	reportsOf(ParseLogContent, rawLog(createEvent(1, "/tmp/a", "10"), avc))
+}
+
+func TestTrailingSlashPair(t *testing.T) {
+	log := rawLog(createEvent(1, "/tmp/a", "10"), useEvent(2, "/tmp/a/", "10"))
+
+	if reports, _ := reportsOf(ParseLogContent, log); len(reports) != 1 {
+		t.Errorf("got %d reports w/o -ignoretrailingslash, want 1", len(reports))
+	}
+	setFlag(t, flagNoSlash, true)
+	if reports, _ := reportsOf(ParseLogContent, log); len(reports) != 0 {
+		t.Errorf("got %d reports with -ignoretrailingslash, want none", len(reports))
+	}
+}
+
+func TestEmptyInodeFields(t *testing.T) {
+	tests := map[string]string{
+		"empty":   "inode= dev=",
+		"missing": "",
+		"inode":   "inode= dev=08:03",
+		"device":  "inode=10 dev=",
+	}
+	for name, fields := range tests {
+		log := rawLog(createEvent(1, "/tmp/a", "10"), useEvent(2, "/tmp/b", "10"))
+		log = strings.ReplaceAll(log, "inode=10 dev=08:03", fields)
+
+		reports, tm := reportsOf(ParseLogContent, log)
+		if len(reports) != 0 {
+			t.Errorf("%s: got %d reports, want none as /tmp/a & /tmp/b can't be correlated", name, len(reports))
+		}
+		if _, ok := tm.history[NoName]; ok || len(tm.history) != 0 {
+			t.Errorf("%s: recorded %d creates, want none", name, len(tm.history))
+		}
+	}
+}
+
+// PATH records are applied in item order, however auditd or ausearch printed
+// them, so a create is recorded before a use in the same event
+func TestInodesInItemOrder(t *testing.T) {
+	msg := "audit(1626882755.001:1)"
+	syscall := `type=SYSCALL msg=` + msg + `: arch=c000003e syscall=264 success=yes exit=0 a0=ffffff9c a1=7ffd a2=ffffff9c a3=7ffd items=2 ppid=1 pid=100 auid=1000 uid=0 gid=0 euid=0 comm="mv" exe="/usr/bin/mv"`
+	item0 := `type=PATH msg=` + msg + `: item=0 name="/tmp/a" inode=10 dev=08:03 mode=0100644 ouid=0 ogid=0 rdev=00:00 nametype=CREATE`
+	item1 := `type=PATH msg=` + msg + `: item=1 name="/tmp/A" inode=10 dev=08:03 mode=0100644 ouid=0 ogid=0 rdev=00:00 nametype=NORMAL`
+
+	orders := map[string][]string{
+		"audit.log": {syscall, item0, item1},
+		"ausearch":  {syscall, item1, item0}, // reversed
+	}
+	for name, lines := range orders {
+		var rs Records
+		rs.AddLines(lines)
+
+		inodes := *rs.GetInodes()
+		if len(inodes) != 2 || inodes[0].Path != "/tmp/a" || inodes[1].Path != "/tmp/A" {
+			t.Errorf("%s: got inodes %v, want /tmp/a (item=0) then /tmp/A (item=1)", name, inodes)
+			continue
+		}
+
+		var reports []Report
+		tm := NewTimeline()
+		tm.OnReport(func(r Report) {
+			reports = append(reports, r)
+		})
+		tm.ApplyInodes(&inodes)
+		if len(reports) != 1 || reports[0].Create.Path != "/tmp/a" || reports[0].Use.Path != "/tmp/A" {
+			t.Errorf("%s: got %d reports, want create of /tmp/a used as /tmp/A", name, len(reports))
+		}
+	}
+}
+
+func TestInodeName(t *testing.T) {
+	tests := []struct {
+		i    Inode
+		want string
+	}{
+		{Inode{Device: "00:39", InodeNum: "2103"}, "00:39|2103"},
+		{Inode{Device: "00:39", InodeNum: "2103", Gen: "7"}, "00:39|2103|7"},
+		{Inode{Device: "00:39", InodeNum: "?"}, NoName},
+		{Inode{Device: "?", InodeNum: "2103"}, NoName},
+	}
+	for _, tt := range tests {
+		if got := tt.i.Name(); got != tt.want {
+			t.Errorf("Name() of dev=%q inode=%q = %q, want %q", tt.i.Device, tt.i.InodeNum, got, tt.want)
+		}
+	}
+}
+
+// Missing targets, ex. of a failed open, are logged with inode=?
+func TestUnknownInode(t *testing.T) {
+	log := rawLog(createEvent(1, "/tmp/a", "10"), useEvent(2, "/tmp/b", "10"))
+	log = strings.ReplaceAll(log, "inode=10", "inode=?")
+
+	reports, tm := reportsOf(ParseLogContent, log)
+	if len(reports) != 0 {
+		t.Errorf("got %d reports, want none as /tmp/a & /tmp/b can't be correlated", len(reports))
+	}
+	if len(tm.history) != 0 {
+		t.Errorf("recorded %d creates, want none", len(tm.history))
+	}
+}
+
+// O_PATH opens don't access the file, so they aren't uses
+func TestOPathOpen(t *testing.T) {
+	tests := map[string]int{
+		"a2=0":            1, // O_RDONLY
+		"a2=200000":       0, // O_PATH
+		"a2=210000":       0, // O_PATH|O_DIRECTORY
+		"a2=7ffd00200000": 1, // pointer, not flags
+	}
+	for flags, want := range tests {
+		use := strings.Replace(useEvent(2, "/tmp/A", "10"), "a2=0", flags, 1)
+		reports, _ := reportsOf(ParseLogContent, rawLog(createEvent(1, "/tmp/a", "10"), use))
+		if len(reports) != want {
+			t.Errorf("%s: got %d reports, want %d", flags, len(reports), want)
+		}
+	}
+}
+
+func TestParseKVPairsWithEquals(t *testing.T) {
+	kv := ParseKVPairs(`item=0 name="config=prod.yaml" inode=10 nametype=CREATE`)
+	want := map[string]string{"item": "0", "name": `"config=prod.yaml"`, "inode": "10", "nametype": "CREATE"}
+	for k, v := range want {
+		if kv[k] != v {
+			t.Errorf("%s = %q, want %q", k, kv[k], v)
+		}
+	}
+}
+
+// Names with "=" survive parsing into Inode.Path
+func TestNameWithEquals(t *testing.T) {
+	log := rawLog(createEvent(1, "/tmp/config=prod.yaml", "10"), useEvent(2, "/tmp/config=PROD.yaml", "10"))
+
+	reports, _ := reportsOf(ParseLogContent, log)
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	r := reports[0]
+	if r.Create.Path != "/tmp/config=prod.yaml" || r.Use.Path != "/tmp/config=PROD.yaml" {
+		t.Errorf("got paths %q & %q, want /tmp/config=prod.yaml & /tmp/config=PROD.yaml", r.Create.Path, r.Use.Path)
+	}
+}
+
+func TestProctitleArgs(t *testing.T) {
+	tests := map[string]struct {
+		proctitle string
+		want      []string
+	}{
+		"none":   {"", nil},
+		"single": {"\ntype=PROCTITLE msg=audit(1626882755.001:1): proctitle=2F62696E2F7368", []string{"/bin/sh"}},
+		"args":   {"\ntype=PROCTITLE msg=audit(1626882755.001:1): proctitle=2F62696E2F7368002D63", []string{"/bin/sh", "-c"}},
+	}
+	for name, tt := range tests {
+		log := rawLog(createEvent(1, "/tmp/a", "10")+tt.proctitle, useEvent(2, "/tmp/A", "10"))
+		reports, _ := reportsOf(ParseLogContent, log)
+		if len(reports) != 1 {
+			t.Fatalf("%s: got %d reports, want 1", name, len(reports))
+		}
+		if got := reports[0].Create.PtArgs; !slices.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+			t.Errorf("%s: PtArgs = %q, want %q", name, got, tt.want)
+		}
+	}
+}
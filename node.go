@@ -0,0 +1,29 @@
+package main
+
+// Node of an event, from the "node=" prefix of its records. Logs collected
+// from several hosts, ex. by audisp-remote, have it.
+func (rs Records) Node() string {
+	for _, r := range rs.Records {
+		if len(r.Node) > 0 {
+			return r.Node
+		}
+	}
+	return ""
+}
+
+// Timeline of events from node, whose violations are passed on to tm. See
+// -nodehost.
+func (tm *Timeline) nodeTimeline(node string) *Timeline {
+	if t, ok := tm.nodes[node]; ok {
+		return t
+	}
+	if tm.nodes == nil {
+		tm.nodes = make(map[string]*Timeline)
+	}
+	t := NewTimeline()
+	t.Host = node
+	t.OnReport(tm.Emit) // t filters its reports
+	t.OnEvent(tm.onEvent)
+	tm.nodes[node] = &t
+	return &t
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Prefix each record with node=
+func onNode(node, event string) string {
+	return "node=" + node + " " + strings.ReplaceAll(event, "\ntype=", "\nnode="+node+" type=")
+}
+
+func TestNodePrefix(t *testing.T) {
+	r := NewRecord(`node=web1 type=CWD msg=audit(1626882755.001:1): cwd="/tmp"`)
+	if r.Node != "web1" || r.Type != "CWD" || r.Msg != "audit(1626882755.001:1)" || r.Body["cwd"] != `"/tmp"` {
+		t.Errorf("got node=%q type=%q msg=%q cwd=%q, want web1, CWD, audit(1626882755.001:1) & \"/tmp\"",
+			r.Node, r.Type, r.Msg, r.Body["cwd"])
+	}
+
+	log := rawLog(onNode("web1", createEvent(1, "/tmp/a", "10")), onNode("web1", useEvent(2, "/tmp/A", "10")))
+	reports, _ := reportsOf(ParseLogContent, log)
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	if len(reports[0].Host) != 0 {
+		t.Errorf("got host %q w/o -nodehost, want none", reports[0].Host)
+	}
+}
+
+// With -nodehost, events of each node are correlated separately & reports
+// are labeled with their node
+func TestNodeHost(t *testing.T) {
+	setFlag(t, flagNodeHost, true)
+
+	log := rawLog(onNode("web1", createEvent(1, "/tmp/a", "10")), onNode("web1", useEvent(2, "/tmp/A", "10")))
+	reports, _ := reportsOf(ParseLogContent, log)
+	if len(reports) != 1 || reports[0].Host != "web1" {
+		t.Fatalf("got %d reports, want 1 of host web1", len(reports))
+	}
+
+	// same inode# on another node isn't the same inode
+	log = rawLog(onNode("web1", createEvent(1, "/tmp/a", "10")), onNode("web2", useEvent(2, "/tmp/A", "10")))
+	if reports, _ := reportsOf(ParseLogContent, log); len(reports) != 0 {
+		t.Errorf("got %d reports across nodes, want none", len(reports))
+	}
+}
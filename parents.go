@@ -0,0 +1,42 @@
+package main
+
+// Directories created, keyed by their absolute path w/o trailing "/". See
+// -parentdirs.
+type DirTable map[string]Inode
+
+// Absolute path of a directory w/o trailing "/", whatever its nametype
+func dirPath(i *Inode) string {
+	return trimTrailingSlash(i.getAbsPath())
+}
+
+// Remember directories created in an event, and report if a use resolved
+// beneath a parent at a created directory's path, but the parent is another
+// inode, i.e. the directory was swapped or recreated by someone else.
+func (tm *Timeline) trackParents(rs *Records) {
+	s, ok := rs.Syscall()
+	if !ok || (!s.Success && !*flagInclFailed) {
+		return
+	}
+
+	for i := range rs.InodeSeq() {
+		if !i.IsDir() || i.Name() == NoName || len(i.Path) == 0 || i.Path == "(null)" {
+			continue
+		}
+		path := dirPath(&i)
+
+		switch nametypeOps[i.Operation] {
+		case OpCreate:
+			tm.dirs[path] = i
+		case OpDelete:
+			delete(tm.dirs, path)
+		}
+		if i.Operation != "PARENT" {
+			continue
+		}
+
+		created, ok := tm.dirs[path]
+		if ok && created.Name() != i.Name() {
+			tm.Report(NewReport(CategoryParentMismatch, &created, &i))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Describe which components of two paths differ, ex. "component 3:
+// tmpfile != TMPFILE". Components are counted from 1, after the leading "/"
+// of absolute paths.
+func diffPaths(a, b string) string {
+	as := strings.Split(strings.TrimPrefix(a, "/"), "/")
+	bs := strings.Split(strings.TrimPrefix(b, "/"), "/")
+
+	var diffs []string
+	for n := 0; n < max(len(as), len(bs)); n++ {
+		var x, y string
+		if n < len(as) {
+			x = as[n]
+		}
+		if n < len(bs) {
+			y = bs[n]
+		}
+		if x != y {
+			diffs = append(diffs, fmt.Sprintf("component %d: %q != %q", n+1, x, y))
+		}
+	}
+	return strings.Join(diffs, "; ")
+}
+
+// Differing components of the create & use paths of a report
+func reportPathDiff(r Report) string {
+	if r.Kind != KindPair {
+		return ""
+	}
+	return diffPaths(r.Create.NormalizedPath(), r.Use.NormalizedPath())
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Compiled from -pathinclude & -pathexclude
+var pathInclude, pathExclude *regexp.Regexp
+
+// Compile -pathinclude & -pathexclude; empty patterns aren't applied
+func setupPathFilter(include, exclude string) error {
+	var err error
+	if len(include) > 0 {
+		if pathInclude, err = regexp.Compile(include); err != nil {
+			return fmt.Errorf("-pathinclude: %v", err)
+		}
+	}
+	if len(exclude) > 0 {
+		if pathExclude, err = regexp.Compile(exclude); err != nil {
+			return fmt.Errorf("-pathexclude: %v", err)
+		}
+	}
+	return nil
+}
+
+// Does re match the create or use path of a report?
+func matchPaths(re *regexp.Regexp, r Report) bool {
+	return re.MatchString(r.Create.NormalizedPath()) || re.MatchString(r.Use.NormalizedPath())
+}
+
+// Keep a report if a path matches -pathinclude & none matches -pathexclude
+func keepPaths(r Report) bool {
+	if pathInclude != nil && !matchPaths(pathInclude, r) {
+		return false
+	}
+	return pathExclude == nil || !matchPaths(pathExclude, r)
+}
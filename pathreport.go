@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Summaries of -report
+var summaryKinds = []string{"paths"}
+
+func validSummary(kind string) bool {
+	for _, k := range summaryKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return len(kind) == 0
+}
+
+// Findings involving a path, as create or use. See -report paths.
+type PathCount struct {
+	Path  string
+	Count int
+	Exes  []string // attributions of creates & uses of the path
+}
+
+// Count findings per confused path, most frequent first
+func countPaths(reports []Report) []PathCount {
+	var counts []PathCount
+	index := make(map[string]int)
+	exes := make(map[string]map[string]bool)
+
+	add := func(i *Inode, seen map[string]bool) {
+		p := i.NormalizedPath()
+		idx, ok := index[p]
+		if !ok {
+			idx = len(counts)
+			index[p] = idx
+			counts = append(counts, PathCount{Path: p})
+			exes[p] = make(map[string]bool)
+		}
+		if !seen[p] { /* create & use of the same path count once */
+			seen[p] = true
+			counts[idx].Count++
+		}
+		if exe := i.Attribution(); !exes[p][exe] {
+			exes[p][exe] = true
+			counts[idx].Exes = append(counts[idx].Exes, exe)
+		}
+	}
+
+	for _, r := range reports {
+		if r.Kind != KindPair {
+			continue
+		}
+		seen := make(map[string]bool)
+		add(r.Create, seen)
+		add(r.Use, seen)
+	}
+
+	sort.SliceStable(counts, func(a, b int) bool {
+		return counts[a].Count > counts[b].Count
+	})
+	return counts
+}
+
+func printPathCounts(counts []PathCount) {
+	for _, c := range counts {
+		fmt.Printf("%6d %s\t(%s)\n", c.Count, c.Path, strings.Join(c.Exes, ", "))
+	}
+}
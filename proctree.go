@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// A process as last seen in the log
+type procInfo struct {
+	Ppid int64
+	Exe  string
+}
+
+// Processes seen in the log, keyed by pid. See -ancestry.
+type ProcTree map[int64]procInfo
+
+// Depth at which ancestry stops, in case pids were reused into a cycle
+const maxAncestry = 32
+
+// Remember the process of an event & its parent
+func (tm *Timeline) trackProcs(rs *Records) {
+	s, ok := rs.Syscall()
+	if !ok || s.Pid == 0 {
+		return
+	}
+	tm.procs[s.Pid] = procInfo{Ppid: s.Ppid, Exe: s.Exe}
+}
+
+// Exes of the ancestors of a process, oldest first & ending with the process,
+// ex. [sshd bash script.sh]. Ancestors that never did a logged syscall are
+// shown by pid, after which the chain can't be followed.
+func (t ProcTree) Ancestry(s Syscall) []string {
+	chain := []string{path.Base(knownExe(s.Exe))}
+	seen := map[int64]bool{s.Pid: true}
+
+	for ppid := s.Ppid; ppid > 0 && len(chain) < maxAncestry; {
+		if seen[ppid] {
+			break
+		}
+		seen[ppid] = true
+
+		parent, ok := t[ppid]
+		if !ok {
+			chain = append(chain, fmt.Sprintf("pid %d", ppid))
+			break
+		}
+		chain = append(chain, path.Base(knownExe(parent.Exe)))
+		ppid = parent.Ppid
+	}
+
+	// reverse, so the oldest ancestor is first
+	for a, b := 0, len(chain)-1; a < b; a, b = a+1, b-1 {
+		chain[a], chain[b] = chain[b], chain[a]
+	}
+	return chain
+}
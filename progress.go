@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// How often progress is printed
+const progressInterval = time.Second
+
+// Value of -progress: "-progress" shows progress when stderr is a terminal,
+// "-progress=force" always shows it
+type progressFlag string
+
+func (p *progressFlag) String() string { return string(*p) }
+func (p *progressFlag) Set(v string) error {
+	switch v {
+	case "true", "force":
+		*p = progressFlag(v)
+	case "false":
+		*p = ""
+	default:
+		return fmt.Errorf("expected -progress or -progress=force")
+	}
+	return nil
+}
+func (p *progressFlag) IsBoolFlag() bool { return true }
+
+// Prints number of processed events to stderr
+type Progress struct {
+	enabled bool
+	events  int
+	max     int  // stop after this many events, see -maxevents
+	stopped bool // see Stop
+	last    time.Time
+}
+
+// Used by the parsers
+var progress Progress
+
+func NewProgress(mode string, max int) Progress {
+	enabled := mode == "force" || (mode == "true" && isTerminal(os.Stderr))
+	return Progress{enabled: enabled, max: max, last: time.Now()}
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Count an event; prints progress at most once per progressInterval
+func (p *Progress) Event() {
+	p.events++
+	if !p.enabled || time.Since(p.last) < progressInterval {
+		return
+	}
+	p.last = time.Now()
+	fmt.Fprintf(os.Stderr, "progress: %d events\n", p.events)
+}
+
+// Were max events processed, or was Stop called? Parsers stop once it's true.
+func (p *Progress) Full() bool {
+	return p.stopped || (p.max > 0 && p.events >= p.max)
+}
+
+// Stop parsers after the current event, ex. for -failfast
+func (p *Progress) Stop() {
+	p.stopped = true
+}
+
+func (p *Progress) Done() {
+	if p.enabled {
+		fmt.Fprintf(os.Stderr, "progress: %d events (done)\n", p.events)
+	}
+}
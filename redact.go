@@ -0,0 +1,98 @@
+package main
+
+import (
+	"regexp"
+)
+
+const redacted = "<redacted>"
+
+// Matches user name in home directories
+var homeRegexp = regexp.MustCompile(`/home/[^/\s'"]+`)
+
+// Set from -redactregex
+var redactRegexp *regexp.Regexp
+
+// Compile -redactregex
+func setupRedaction(expr string) error {
+	if len(expr) == 0 {
+		return nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	redactRegexp = re
+	return nil
+}
+
+// Hide user names in home directories and matches of -redactregex
+func redactStr(s string) string {
+	s = homeRegexp.ReplaceAllString(s, "/home/"+redacted)
+	if redactRegexp != nil {
+		s = redactRegexp.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+func redactInode(i *Inode) *Inode {
+	if i == nil {
+		return nil
+	}
+	c := *i
+	c.Path = redactStr(c.Path)
+	c.Sockaddr = redactStr(c.Sockaddr)
+	c.Cwd = redactStr(c.Cwd)
+	c.Mount = redactStr(c.Mount)
+	c.Exe = redactStr(c.Exe)
+	c.Proctitle = redactStr(c.Proctitle)
+	if len(c.PtArgs) > 0 {
+		c.PtArgs = make([]string, len(i.PtArgs))
+		for n, arg := range i.PtArgs {
+			c.PtArgs[n] = redactStr(arg)
+		}
+	}
+	if len(c.Argv) > 0 {
+		c.Argv = make([]string, len(i.Argv))
+		for n, arg := range i.Argv {
+			c.Argv[n] = redactStr(arg)
+		}
+	}
+	c.Syscall.Exe = redactStr(c.Syscall.Exe)
+	c.Syscall.Cmd = redactStr(c.Syscall.Cmd)
+	c.Syscall.Comm = redactStr(c.Syscall.Comm)
+	return &c
+}
+
+// Copy of report for output, without sensitive path components. Detection
+// is done on the original report.
+func redactReport(r Report) Report {
+	r.Create = redactInode(r.Create)
+	r.Use = redactInode(r.Use)
+	r.Explanation = redactStr(r.Explanation)
+	if len(r.Ancestry) > 0 {
+		ancestry := make([]string, len(r.Ancestry))
+		for n, exe := range r.Ancestry {
+			ancestry[n] = redactStr(exe)
+		}
+		r.Ancestry = ancestry
+	}
+	if len(r.AVCs) > 0 {
+		avcs := make([]AVC, len(r.AVCs))
+		for n, a := range r.AVCs {
+			a.Comm = redactStr(a.Comm)
+			a.Name = redactStr(a.Name)
+			a.Profile = redactStr(a.Profile)
+			avcs[n] = a
+		}
+		r.AVCs = avcs
+	}
+	if len(r.Chain) > 0 {
+		chain := make([]Inode, len(r.Chain))
+		for n := range r.Chain {
+			chain[n] = *redactInode(&r.Chain[n])
+		}
+		r.Chain = chain
+	}
+	return r
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactInode(t *testing.T) {
+	i := &Inode{Path: "/home/alice/run/app.sock", Sockaddr: "/home/alice/run/app.sock", Cwd: "/home/alice"}
+	c := redactInode(i)
+	for name, got := range map[string]string{"Path": c.Path, "Sockaddr": c.Sockaddr, "Cwd": c.Cwd} {
+		if strings.Contains(got, "alice") {
+			t.Errorf("%s = %q, want the user name redacted", name, got)
+		}
+	}
+	if i.Sockaddr != "/home/alice/run/app.sock" {
+		t.Errorf("original changed to %q; detection uses it", i.Sockaddr)
+	}
+}
+
+func TestRedactAVCs(t *testing.T) {
+	setFlag(t, &redactRegexp, regexp.MustCompile("secret"))
+
+	avc := AVC{Comm: "secret-tool", Name: "/home/alice/secret", Profile: "/usr/bin/secret-tool"}
+	r := redactReport(Report{Use: &Inode{}, AVCs: []AVC{avc}})
+	a := r.AVCs[0]
+	for name, got := range map[string]string{"Comm": a.Comm, "Name": a.Name, "Profile": a.Profile} {
+		if strings.Contains(got, "secret") || strings.Contains(got, "alice") {
+			t.Errorf("%s = %q, want it redacted", name, got)
+		}
+	}
+	if avc.Comm != "secret-tool" {
+		t.Errorf("original changed to %q", avc.Comm)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "time"
+
+// Paces events of an input by their timestamps, as if the log was live. See
+// -replay.
+type Replayer struct {
+	last time.Time // of the previous event
+}
+
+// Sleep for the time between the previous event & rs, divided by -speed
+func (p *Replayer) Wait(rs *Records) {
+	if !*flagReplay || len(rs.Records) == 0 {
+		return
+	}
+	t, ok := parseMsgTime(rs.Records[0].Msg)
+	if !ok {
+		return
+	}
+
+	if !p.last.IsZero() && t.After(p.last) && *flagSpeed > 0 {
+		time.Sleep(time.Duration(float64(t.Sub(p.last)) / *flagSpeed))
+	}
+	if t.After(p.last) {
+		p.last = t
+	}
+}
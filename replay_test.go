@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Inputs are replayed from their own start, not from the previous input's
+// last event
+func TestReplayPerInput(t *testing.T) {
+	setFlag(t, flagReplay, true)
+	setFlag(t, flagSpeed, 1.0)
+
+	early := rawLog(createEvent(1, "/tmp/a", "10"))
+	late := strings.ReplaceAll(rawLog(createEvent(2, "/tmp/b", "11")), "1626882755.", "1626882757.")
+
+	tm := NewTimeline()
+	start := time.Now()
+	ParseLogContent(&tm, []byte(early))
+	ParseLogContent(&tm, []byte(late)) // logged 2s after early
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("replaying 2 single-event inputs took %v", elapsed)
+	}
+}
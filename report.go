@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Why a create-use pair was reported. Each is described once, in categories.
+type Category string
+
+const (
+	CategoryNone            Category = ""
+	CategoryPathMismatch    Category = "path-mismatch"
+	CategoryCaseMismatch    Category = "case-mismatch"
+	CategoryRecreate        Category = "recreate"
+	CategoryFdMismatch      Category = "fd-mismatch"
+	CategoryProcFdMismatch  Category = "procfd-mismatch"
+	CategoryAuditBlinded    Category = "audit-blinded"
+	CategoryContextMismatch Category = "context-mismatch"
+	CategoryWriteAfterCheck Category = "write-after-check"
+	CategoryParentMismatch  Category = "parent-mismatch"
+)
+
+// What a report is about
+type ReportKind string
+
+const (
+	KindPair   ReportKind = ""       // create-use pair of an inode; Create & Use are set
+	KindConfig ReportKind = "config" // audit config change blinding detection, see -watchrules; only Use & Config are set
+)
+
+// How concerning a reported create-use pair is
+type Severity int
+
+const (
+	SeverityLow    Severity = iota // create & use within the same process
+	SeverityMedium                 // same executable, different processes
+	SeverityHigh                   // different executables
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Confusions across process or executable boundaries are more likely to be
+// an attack than a process confusing itself.
+func (r Report) Severity() Severity {
+	switch {
+	case r.Kind == KindConfig: /* blinds detection */
+		return SeverityHigh
+	case r.Hardlink:
+		return SeverityLow
+	case r.Create.Syscall.Exe != r.Use.Syscall.Exe:
+		return SeverityHigh
+	case r.Create.Syscall.Pid != r.Use.Syscall.Pid:
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+// Valid values for -jsonmode: a one-line array, an indented array, or one
+// object per line as found
+var jsonModes = []string{"compact", "pretty", "ndjson"}
+
+func validJSONMode(mode string) bool {
+	for _, m := range jsonModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Print reports or groups as json, per -jsonmode
+func printJSON[T any](output []T, mode string) {
+	if output == nil {
+		output = []T{} /* "[]" rather than "null" */
+	}
+
+	if mode == "ndjson" {
+		for _, o := range output {
+			line, _ := json.Marshal(o)
+			fmt.Println(string(line))
+		}
+		return
+	}
+
+	var v any = output
+	if *flagEnvelope {
+		v = NewEnvelope(output)
+	}
+
+	var result []byte
+	if mode == "pretty" {
+		result, _ = json.MarshalIndent(v, "", "  ")
+	} else {
+		result, _ = json.Marshal(v)
+	}
+	fmt.Println(string(result))
+}
+
+// Valid values for -sort
+var sortOrders = []string{"chrono", "exe", "path", "severity"}
+
+func validSortOrder(by string) bool {
+	for _, o := range sortOrders {
+		if o == by {
+			return true
+		}
+	}
+	return false
+}
+
+// Order reports in place. Reports are already chronological, so ties keep
+// their chronological order.
+func sortReports(reports []Report, by string) {
+	var less func(a, b Report) bool
+
+	switch by {
+	case "exe":
+		less = func(a, b Report) bool { return a.Use.Syscall.Exe < b.Use.Syscall.Exe }
+	case "path":
+		less = func(a, b Report) bool { return a.Use.NormalizedPath() < b.Use.NormalizedPath() }
+	case "severity":
+		less = func(a, b Report) bool { return a.Severity() > b.Severity() }
+	default: /* chrono */
+		return
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		return less(reports[i], reports[j])
+	})
+}
+
+// Describe why a create-use pair was reported
+func explain(category Category, create, use *Inode) string {
+	if category == CategoryFdMismatch {
+		return fmt.Sprintf("fd opened for path %s (inode %s); "+
+			"later operation on the same fd observed path %s (inode %s)",
+			create.Path, create.Name(), use.Path, use.Name())
+	}
+	if category == CategoryProcFdMismatch {
+		return fmt.Sprintf("fd opened for path %s (inode %s); "+
+			"re-opening it via %s reached inode %s",
+			create.NormalizedPath(), create.Name(), use.NormalizedPath(), use.Name())
+	}
+	if category == CategoryWriteAfterCheck {
+		return fmt.Sprintf("path %s was checked as inode %s; "+
+			"later write to the same path (open or truncate) reached inode %s",
+			create.NormalizedPath(), create.Name(), use.Name())
+	}
+	if category == CategoryParentMismatch {
+		return fmt.Sprintf("directory %s was created as inode %s; "+
+			"later use resolved beneath parent inode %s at the same path",
+			dirPath(create), create.Name(), use.Name())
+	}
+	if category == CategoryContextMismatch {
+		return fmt.Sprintf("create recorded SELinux context %s for inode %s; "+
+			"use observed context %s", create.Obj, create.Name(), use.Obj)
+	}
+
+	cPATH, uPATH := create.NormalizedPath(), use.NormalizedPath()
+
+	why := "paths differ"
+	if cPATH != create.Path || uPATH != use.Path {
+		why = fmt.Sprintf("paths differ after normalization (%s != %s)", cPATH, uPATH)
+	}
+
+	return fmt.Sprintf("create recorded path %s (inode %s); "+
+		"use observed path %s for same inode; %s",
+		create.Path, create.Name(), use.Path, why)
+}
+
+// Reports sharing the same inode, exe or path
+type ReportGroup struct {
+	Key     string
+	Reports []Report
+}
+
+// Valid values for -groupby
+var groupByKeys = []string{"inode", "exe", "path"}
+
+func validGroupBy(by string) bool {
+	for _, k := range groupByKeys {
+		if k == by {
+			return true
+		}
+	}
+	return false
+}
+
+func groupKey(r Report, by string) string {
+	switch by {
+	case "exe":
+		return knownExe(r.Use.Syscall.Exe)
+	case "path":
+		return r.Use.NormalizedPath()
+	}
+	if r.Kind == KindConfig {
+		return string(r.Category)
+	}
+	return r.Create.Name() /* inode */
+}
+
+// Group reports, keeping groups in order of their first report
+func groupReports(reports []Report, by string) []ReportGroup {
+	var groups []ReportGroup
+	index := make(map[string]int)
+
+	for _, r := range reports {
+		key := groupKey(r, by)
+		idx, ok := index[key]
+		if !ok {
+			idx = len(groups)
+			index[key] = idx
+			groups = append(groups, ReportGroup{Key: key})
+		}
+		groups[idx].Reports = append(groups[idx].Reports, r)
+	}
+	return groups
+}
+
+// Print each group with its creates, followed by the confused uses of each
+// create.
+func printGroups(groups []ReportGroup, by string) {
+	for _, g := range groups {
+		fmt.Printf("%s %s\n", by, g.Key)
+
+		var last *Inode
+		for _, r := range g.Reports {
+			if r.Kind == KindConfig {
+				fmt.Printf("    %s %s\n", r.useLabel(), r.Config)
+				continue
+			}
+			if last == nil || last.Msg != r.Create.Msg || last.Name() != r.Create.Name() {
+				fmt.Printf("  %s%v\n", r.createLabel(), r.Create)
+				last = r.Create
+			}
+			fmt.Printf("    %s%v\n", r.useLabel(), r.Use)
+		}
+	}
+}
+
+// Label of the second inode of a report; it's a create for -recreate
+func (r Report) useLabel() string {
+	label := "USE"
+	switch r.Category {
+	case CategoryRecreate:
+		label = "RECREATE"
+	case CategoryFdMismatch:
+		label = "FDUSE"
+	case CategoryProcFdMismatch:
+		label = "PROCFD"
+	case CategoryAuditBlinded:
+		label = "CONFIG"
+	case CategoryWriteAfterCheck:
+		label = "WRITE"
+	case CategoryParentMismatch:
+		label = "PARENT"
+	}
+	return paint(colorRed, label)
+}
+
+// Label of the first inode of a report; it's a check for -writeaftercheck
+func (r Report) createLabel() string {
+	if r.Category == CategoryWriteAfterCheck {
+		return paint(colorYellow, "CHECK")
+	}
+	return paint(colorYellow, "CREATE")
+}
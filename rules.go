@@ -0,0 +1,69 @@
+package main
+
+import "strings"
+
+// Detection heuristic for a create-use pair of the same inode. Rules are
+// checked for each use of a created inode, after -samepid & -sameexe.
+type Rule interface {
+	// Report the pair, if it's a confusion
+	Check(create, use *Inode) (Report, bool)
+}
+
+// Check rule for every create-use pair, in addition to the rules already
+// added. The built-in PathRule is added by NewTimeline.
+func (tm *Timeline) AddRule(rule Rule) {
+	tm.rules = append(tm.rules, rule)
+}
+
+// Built-in rule: use reached the inode by another name than its create
+type PathRule struct {
+	Equal Comparator // defaults to strict comparison
+}
+
+func (PathRule) Categories() []Category {
+	return []Category{CategoryPathMismatch, CategoryCaseMismatch}
+}
+
+func (pr PathRule) Check(create, use *Inode) (Report, bool) {
+	category := comparePaths(pr.Equal, create, use)
+	if category == CategoryNone {
+		return Report{}, false
+	}
+	return NewReport(category, create, use), true
+}
+
+// Rule for -selinux: the inode has another SELinux object context at use
+// than at create, ex. it was relabeled or swapped in between.
+type ContextRule struct{}
+
+func (ContextRule) Categories() []Category {
+	return []Category{CategoryContextMismatch}
+}
+
+func (ContextRule) Check(create, use *Inode) (Report, bool) {
+	if len(create.Obj) == 0 || len(use.Obj) == 0 || create.Obj == use.Obj {
+		return Report{}, false
+	}
+	return NewReport(CategoryContextMismatch, create, use), true
+}
+
+// Compare paths of a create-use pair for the same inode. Returns the kind of
+// confusion found, or CategoryNone if there is none.
+func comparePaths(equal Comparator, create, use *Inode) Category {
+	if equal == nil {
+		equal = comparators["strict"]
+	}
+
+	cPATH, uPATH := create.NormalizedPath(), use.NormalizedPath()
+	if *flagNoSlash {
+		cPATH, uPATH = trimTrailingSlash(cPATH), trimTrailingSlash(uPATH)
+	}
+
+	switch {
+	case equal(cPATH, uPATH):
+		return CategoryNone
+	case strings.EqualFold(cPATH, uPATH):
+		return CategoryCaseMismatch
+	}
+	return CategoryPathMismatch
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+// Set flag to v for the duration of the test
+func setFlag[T any](t *testing.T, flag *T, v T) {
+	old := *flag
+	*flag = v
+	t.Cleanup(func() { *flag = old })
+}
+
+func TestTrailingSlash(t *testing.T) {
+	const file, dir = 0100644, 040755
+	tests := []struct {
+		create, use string
+		mode        uint16
+		ignore      bool // -ignoretrailingslash
+		want        Category
+	}{
+		{"/a", "/a/", file, false, CategoryPathMismatch},
+		{"/a/", "/a", file, false, CategoryPathMismatch},
+		{"/a", "/a/", file, true, CategoryNone},
+		{"/a/", "/a", file, true, CategoryNone},
+		{"/a", "/a/", dir, false, CategoryNone}, // always trimmed for dirs
+		{"/a", "/A/", file, true, CategoryCaseMismatch},
+		{"/", "/", file, true, CategoryNone},
+	}
+	for _, tt := range tests {
+		setFlag(t, flagNoSlash, tt.ignore)
+		create := &Inode{Path: tt.create, Mode: tt.mode}
+		use := &Inode{Path: tt.use, Mode: tt.mode}
+		if got := comparePaths(nil, create, use); got != tt.want {
+			t.Errorf("%s vs %s (mode %o, -ignoretrailingslash=%v) = %q, want %q",
+				tt.create, tt.use, tt.mode, tt.ignore, got, tt.want)
+		}
+	}
+}
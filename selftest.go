@@ -0,0 +1,76 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+)
+
+// Bundled examples & their expected findings
+//
+//go:embed examples/*.auditd examples/*.golden
+var selftestFS embed.FS
+
+// Examples checked by -selftest; each has a .auditd log & a .golden file
+var selftestCases = []string{
+	"examples/logs-1",
+	"examples/logs-2",
+	"examples/logs-2-interpreted",
+	"examples/logs-3-benign", // no findings
+}
+
+// Line of golden file for a report. Doesn't depend on syscall names, since
+// ausyscall may be unavailable.
+func goldenLine(r Report) string {
+	if r.Kind == KindConfig {
+		return fmt.Sprintf("%s %s %s", r.Category, r.Use.Msg, r.Config)
+	}
+	return fmt.Sprintf("%s %s %s %s -> %s %s", r.Category, r.Create.Name(),
+		r.Create.Msg, r.Create.NormalizedPath(), r.Use.Msg, r.Use.NormalizedPath())
+}
+
+// Findings for a bundled example, as in its golden file
+func selftestFindings(name string) (string, error) {
+	content, err := selftestFS.ReadFile(name + ".auditd")
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	tm := NewTimeline()
+	tm.OnReport(func(r Report) {
+		result.WriteString(goldenLine(r) + "\n")
+	})
+	ParseLogContent(&tm, content)
+
+	return result.String(), nil
+}
+
+// Run detector against bundled examples & compare with golden files. Should
+// be run without other options, since they change the findings.
+func SelfTest() bool {
+	pass := true
+	for _, name := range selftestCases {
+		got, err := selftestFindings(name)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", name, err)
+			pass = false
+			continue
+		}
+
+		want, err := selftestFS.ReadFile(name + ".golden")
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", name, err)
+			pass = false
+			continue
+		}
+
+		if got != string(want) {
+			fmt.Printf("FAIL %s\n--- want\n%s--- got\n%s", name, want, got)
+			pass = false
+			continue
+		}
+		fmt.Printf("ok   %s\n", name)
+	}
+	return pass
+}
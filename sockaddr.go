@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// Address family of Unix domain sockets (see man 7 unix)
+const AF_UNIX = 1
+
+// Decode path of an AF_UNIX sockaddr, as hex-encoded by auditd in the saddr
+// field of SOCKADDR records. Abstract socket names are prefixed with "@".
+func DecodeUnixSockaddr(saddr string) (string, bool) {
+	raw, err := hex.DecodeString(saddr)
+	if err != nil || len(raw) < 2 {
+		return "", false
+	}
+
+	// struct sockaddr_un { sa_family_t sun_family; char sun_path[108]; }
+	if binary.LittleEndian.Uint16(raw[:2]) != AF_UNIX {
+		return "", false
+	}
+	sunPath := raw[2:]
+	if len(sunPath) == 0 {
+		return "", false /* unnamed socket */
+	}
+
+	// abstract socket
+	if sunPath[0] == 0 {
+		return "@" + string(bytes.TrimRight(sunPath[1:], "\x00")), true
+	}
+
+	if idx := bytes.IndexByte(sunPath, 0); idx >= 0 {
+		sunPath = sunPath[:idx]
+	}
+	return string(sunPath), true
+}
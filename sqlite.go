@@ -0,0 +1,12 @@
+//go:build sqlite
+
+package main
+
+// Pure-Go SQLite driver for -sqlite. Build with:
+//
+//	go build -tags sqlite
+import _ "modernc.org/sqlite"
+
+func init() {
+	sqliteDriver = "sqlite"
+}
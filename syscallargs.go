@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// How a syscall argument is decoded for -syscallargs
+type argKind int
+
+const (
+	argPtr     argKind = iota // address, ex. of a path; contents aren't logged
+	argFd                     // fd, or AT_FDCWD for dirfds
+	argInt                    // ex. a length
+	argMode                   // file mode, in octal
+	argOFlags                 // flags of open, ex. O_WRONLY|O_CREAT
+	argATFlags                // AT_* flags, ex. AT_SYMLINK_NOFOLLOW
+)
+
+type argSpec struct {
+	name string
+	kind argKind
+}
+
+// Arguments of syscalls, in order of a0..a3 (x86_64)
+var syscallArgSchema = map[string][]argSpec{
+	"read":       {{"fd", argFd}, {"buf", argPtr}, {"count", argInt}},
+	"write":      {{"fd", argFd}, {"buf", argPtr}, {"count", argInt}},
+	"open":       {{"pathname", argPtr}, {"flags", argOFlags}, {"mode", argMode}},
+	"close":      {{"fd", argFd}},
+	"stat":       {{"pathname", argPtr}, {"statbuf", argPtr}},
+	"lstat":      {{"pathname", argPtr}, {"statbuf", argPtr}},
+	"access":     {{"pathname", argPtr}, {"mode", argInt}},
+	"connect":    {{"sockfd", argFd}, {"addr", argPtr}, {"addrlen", argInt}},
+	"bind":       {{"sockfd", argFd}, {"addr", argPtr}, {"addrlen", argInt}},
+	"execve":     {{"filename", argPtr}, {"argv", argPtr}, {"envp", argPtr}},
+	"truncate":   {{"path", argPtr}, {"length", argInt}},
+	"ftruncate":  {{"fd", argFd}, {"length", argInt}},
+	"rename":     {{"oldpath", argPtr}, {"newpath", argPtr}},
+	"mkdir":      {{"pathname", argPtr}, {"mode", argMode}},
+	"rmdir":      {{"pathname", argPtr}},
+	"creat":      {{"pathname", argPtr}, {"mode", argMode}},
+	"link":       {{"oldpath", argPtr}, {"newpath", argPtr}},
+	"unlink":     {{"pathname", argPtr}},
+	"symlink":    {{"target", argPtr}, {"linkpath", argPtr}},
+	"chmod":      {{"pathname", argPtr}, {"mode", argMode}},
+	"chown":      {{"pathname", argPtr}, {"owner", argInt}, {"group", argInt}},
+	"openat":     {{"dirfd", argFd}, {"pathname", argPtr}, {"flags", argOFlags}, {"mode", argMode}},
+	"mkdirat":    {{"dirfd", argFd}, {"pathname", argPtr}, {"mode", argMode}},
+	"fchownat":   {{"dirfd", argFd}, {"pathname", argPtr}, {"owner", argInt}, {"group", argInt}},
+	"newfstatat": {{"dirfd", argFd}, {"pathname", argPtr}, {"statbuf", argPtr}, {"flags", argATFlags}},
+	"unlinkat":   {{"dirfd", argFd}, {"pathname", argPtr}, {"flags", argATFlags}},
+	"renameat":   {{"olddirfd", argFd}, {"oldpath", argPtr}, {"newdirfd", argFd}, {"newpath", argPtr}},
+	"linkat":     {{"olddirfd", argFd}, {"oldpath", argPtr}, {"newdirfd", argFd}, {"newpath", argPtr}},
+	"symlinkat":  {{"target", argPtr}, {"newdirfd", argFd}, {"linkpath", argPtr}},
+	"fchmodat":   {{"dirfd", argFd}, {"pathname", argPtr}, {"mode", argMode}},
+	"faccessat":  {{"dirfd", argFd}, {"pathname", argPtr}, {"mode", argInt}},
+	"renameat2":  {{"olddirfd", argFd}, {"oldpath", argPtr}, {"newdirfd", argFd}, {"newpath", argPtr}},
+	"execveat":   {{"dirfd", argFd}, {"pathname", argPtr}, {"argv", argPtr}, {"envp", argPtr}},
+	"statx":      {{"dirfd", argFd}, {"pathname", argPtr}, {"flags", argATFlags}, {"mask", argInt}},
+	"faccessat2": {{"dirfd", argFd}, {"pathname", argPtr}, {"mode", argInt}, {"flags", argATFlags}},
+}
+
+// AT_* flags of *at syscalls
+var atFlags = map[string]uint64{
+	"AT_SYMLINK_NOFOLLOW": 0x100,
+	"AT_REMOVEDIR":        0x200,
+	"AT_SYMLINK_FOLLOW":   0x400,
+	"AT_NO_AUTOMOUNT":     0x800,
+	"AT_EMPTY_PATH":       0x1000,
+}
+
+// Names of the set flags, ex. "O_WRONLY|O_CREAT". Leftover bits are shown
+// in hex.
+func flagNames(v uint64, flags map[string]uint64) string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	// multi-bit flags first, ex. O_SYNC before O_DSYNC
+	sort.Slice(names, func(a, b int) bool {
+		return flags[names[a]] > flags[names[b]]
+	})
+
+	var set []string
+	for _, name := range names {
+		if f := flags[name]; f != 0 && v&f == f {
+			set = append(set, name)
+			v &^= f
+		}
+	}
+	if v != 0 {
+		set = append(set, "0x"+strconv.FormatUint(v, 16))
+	}
+	sort.Strings(set)
+	return strings.Join(set, "|")
+}
+
+func decodeArg(v uint64, kind argKind) string {
+	switch kind {
+	case argFd:
+		if int32(v) == AT_FDCWD {
+			return "AT_FDCWD"
+		}
+		return fmt.Sprint(int32(v))
+	case argInt:
+		return fmt.Sprint(v)
+	case argMode:
+		if v == 0 {
+			return "0"
+		}
+		return "0" + strconv.FormatUint(v, 8)
+	case argOFlags:
+		access := [...]string{"O_RDONLY", "O_WRONLY", "O_RDWR", "O_ACCMODE"}[v&O_ACCMODE]
+		if rest := v &^ O_ACCMODE; rest != 0 {
+			openFlags := make(map[string]uint64)
+			for name, f := range symbolicArgs {
+				if strings.HasPrefix(name, "O_") && f&O_ACCMODE == 0 {
+					openFlags[name] = f
+				}
+			}
+			return access + "|" + flagNames(rest, openFlags)
+		}
+		return access
+	case argATFlags:
+		if v == 0 {
+			return "0"
+		}
+		return flagNames(v, atFlags)
+	}
+	return "0x" + strconv.FormatUint(v, 16)
+}
+
+// Arguments of the syscall by name, for the syscalls in syscallArgSchema.
+// See -syscallargs.
+func (s Syscall) NamedArgs() map[string]string {
+	schema, ok := syscallArgSchema[s.Name]
+	if !ok {
+		return nil
+	}
+
+	args := make(map[string]string)
+	for n, spec := range schema {
+		v := [...]uint64{s.A0, s.A1, s.A2, s.A3}[n]
+		args[spec.name] = decodeArg(v, spec.kind)
+	}
+	return args
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dumps of ausyscall older than this are refreshed, ex. after an upgrade of
+// the audit userspace
+const syscallCacheAge = 30 * 24 * time.Hour
+
+// File caching "ausyscall --dump" output of this machine's arch, ex.
+// ~/.cache/name-confusion/ausyscall-amd64.tsv
+func syscallCacheFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, toolName(), "ausyscall-"+runtime.GOARCH+".tsv"), nil
+}
+
+// Read cached dump, unless it's stale
+func readSyscallCache() (string, bool) {
+	file, err := syscallCacheFile()
+	if err != nil {
+		return "", false
+	}
+	fi, err := os.Stat(file)
+	if err != nil || time.Since(fi.ModTime()) > syscallCacheAge {
+		return "", false
+	}
+	content, err := os.ReadFile(file)
+	return string(content), err == nil && len(content) > 0
+}
+
+func writeSyscallCache(dump string) error {
+	file, err := syscallCacheFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(dump), 0644)
+}
+
+// Dump of the built-in x86_64 tables, in the format of "ausyscall --dump".
+// Only syscalls the detector knows of are named. Other archs number their
+// syscalls differently, so there are none for them.
+func embeddedSyscallDump(goarch string) string {
+	if goarch != "amd64" {
+		return ""
+	}
+
+	names := make(map[uint64]string)
+	for _, table := range []map[string]uint64{
+		pathSyscalls, openSyscalls, fdSyscalls, dirfdSyscalls,
+		checkSyscalls, linkSyscalls, {"close": 3},
+	} {
+		for name, num := range table {
+			names[num] = name
+		}
+	}
+
+	var dump strings.Builder
+	for num, name := range names {
+		dump.WriteString(strconv.FormatUint(num, 10) + "\t" + name + "\n")
+	}
+	return dump.String()
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedSyscallDump(t *testing.T) {
+	if dump := embeddedSyscallDump("amd64"); !strings.Contains(dump, "257\topenat\n") {
+		t.Errorf("amd64 dump lacks openat: %q", dump)
+	}
+	if dump := embeddedSyscallDump("arm64"); len(dump) != 0 {
+		t.Errorf("arm64 dump has x86_64 names: %q", dump)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Numbers of syscalls that operate on paths (x86_64). Used when ausyscall is
+// unavailable to resolve names given on the command line.
+var pathSyscalls = map[string]uint64{
+	"open":              2,
+	"stat":              4,
+	"lstat":             6,
+	"access":            21,
+	"connect":           42,
+	"bind":              49,
+	"execve":            59,
+	"truncate":          76,
+	"ftruncate":         77,
+	"chdir":             80,
+	"rename":            82,
+	"mkdir":             83,
+	"rmdir":             84,
+	"creat":             85,
+	"link":              86,
+	"unlink":            87,
+	"symlink":           88,
+	"readlink":          89,
+	"chmod":             90,
+	"chown":             92,
+	"lchown":            94,
+	"utime":             132,
+	"mknod":             133,
+	"statfs":            137,
+	"chroot":            161,
+	"mount":             165,
+	"umount2":           166,
+	"setxattr":          188,
+	"lsetxattr":         189,
+	"getxattr":          191,
+	"lgetxattr":         192,
+	"listxattr":         194,
+	"removexattr":       197,
+	"utimes":            235,
+	"openat":            257,
+	"mkdirat":           258,
+	"mknodat":           259,
+	"fchownat":          260,
+	"futimesat":         261,
+	"newfstatat":        262,
+	"unlinkat":          263,
+	"renameat":          264,
+	"linkat":            265,
+	"symlinkat":         266,
+	"readlinkat":        267,
+	"fchmodat":          268,
+	"faccessat":         269,
+	"utimensat":         280,
+	"name_to_handle_at": 303,
+	"renameat2":         316,
+	"execveat":          322,
+	"statx":             332,
+	"open_tree":         428,
+	"move_mount":        429,
+	"openat2":           437,
+	"faccessat2":        439,
+}
+
+// Set of syscalls given by name or number, ex. "open,openat,85"
+type SyscallSet struct {
+	names   map[string]bool
+	numbers map[uint64]bool
+}
+
+func NewSyscallSet(list string) SyscallSet {
+	set := SyscallSet{
+		names:   make(map[string]bool),
+		numbers: make(map[uint64]bool),
+	}
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		if num, err := strconv.ParseUint(entry, 10, 64); err == nil {
+			set.numbers[num] = true
+			continue
+		}
+		set.names[entry] = true
+
+		// for logs w/o syscall names
+		if num, ok := pathSyscalls[entry]; ok {
+			set.numbers[num] = true
+		}
+	}
+	return set
+}
+
+func (set SyscallSet) Empty() bool {
+	return len(set.names) == 0 && len(set.numbers) == 0
+}
+
+func (set SyscallSet) Contains(s Syscall) bool {
+	if len(s.Name) > 0 {
+		return set.names[s.Name] || set.numbers[s.Number]
+	}
+	return set.numbers[s.Number]
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Log member of a tar archive
+type tarMember struct {
+	Name    string
+	Content []byte
+}
+
+// Rotation of a log, ex. 2 for audit.log.2 or audit.log.2.gz; 0 for audit.log
+func logRotation(name string) int {
+	base := path.Base(name)
+	for _, d := range decompressors {
+		base = strings.TrimSuffix(base, d.Ext)
+	}
+	idx := strings.LastIndex(base, ".log.")
+	if idx < 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(base[idx+len(".log."):])
+	return n
+}
+
+// Call fn for each log (*.log*) in a tar archive. The archive & its members
+// may be compressed (see ReadLogFile). Rotated logs are older, so they're
+// passed first, ex. audit.log.2, audit.log.1 & then audit.log.
+func ReadTarLogs(file string, fn func(member string, content []byte)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := decompress(file, f)
+	if err != nil {
+		return err
+	}
+
+	var members []tarMember
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if match, _ := path.Match("*.log*", path.Base(hdr.Name)); !match || hdr.Typeflag != tar.TypeReg {
+			if *flagVerbose {
+				log.Printf("skipping %s in %s", hdr.Name, file)
+			}
+			continue
+		}
+
+		mr, err := decompress(hdr.Name, tr)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(mr)
+		if err != nil {
+			return err
+		}
+		members = append(members, tarMember{hdr.Name, content})
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		if di, dj := path.Dir(members[i].Name), path.Dir(members[j].Name); di != dj {
+			return di < dj
+		}
+		return logRotation(members[i].Name) > logRotation(members[j].Name)
+	})
+
+	for _, m := range members {
+		fn(m.Name, m.Content)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path"
+	"runtime/debug"
+	"strings"
+)
+
+// Version of the build, ex. "v1.2.0" for go install, or a pseudo-version
+// with the commit when built from a checkout ("+dirty" if it had changes)
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	v := info.Main.Version
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	// pseudo-versions already have the revision, ex. v0.0.0-20210721-3eedb64f0a1c
+	if len(revision) > 0 && !strings.Contains(v, revision) {
+		v += " " + revision
+		if modified == "true" {
+			v += "+dirty"
+		}
+	}
+	return v
+}
+
+// Name of the tool, from its module path
+func toolName() string {
+	if info, ok := debug.ReadBuildInfo(); ok && len(info.Main.Path) > 0 {
+		return path.Base(info.Main.Path)
+	}
+	return "name-confusion"
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Attempts & timeout of each POST to a webhook
+const (
+	webhookTries   = 3
+	webhookTimeout = 10 * time.Second
+)
+
+// Findings POSTed as a JSON array of reports to a URL, see -webhook
+type Webhook struct {
+	url     string
+	batch   int // reports per POST
+	pending []Report
+	client  *http.Client
+}
+
+func NewWebhook(url string, batch int) *Webhook {
+	if batch < 1 {
+		batch = 1
+	}
+	return &Webhook{
+		url:    url,
+		batch:  batch,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Queue a report, posting the batch once it's full
+func (w *Webhook) Send(r Report) {
+	w.pending = append(w.pending, r)
+	if len(w.pending) >= w.batch {
+		w.Flush()
+	}
+}
+
+// Post queued reports. Failures are logged, as findings are still output.
+func (w *Webhook) Flush() {
+	if len(w.pending) == 0 {
+		return
+	}
+	if err := w.post(w.pending); err != nil {
+		log.Printf("cannot post %d findings to webhook: %v", len(w.pending), err)
+	}
+	w.pending = nil
+}
+
+func (w *Webhook) Close() {
+	w.Flush()
+}
+
+// POST reports, retrying with backoff on network errors, 429 & 5xx
+func (w *Webhook) post(reports []Report) error {
+	body, err := json.Marshal(reports)
+	if err != nil {
+		return err
+	}
+
+	backoff := time.Second
+	for try := 1; ; try++ {
+		err = w.postOnce(body)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(permanentError); ok || try == webhookTries {
+			return err
+		}
+		if *flagVerbose {
+			log.Printf("webhook: %v; retrying in %v", err, backoff)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// A failure that won't go away by retrying, ex. 400 Bad Request
+type permanentError struct {
+	error
+}
+
+func (w *Webhook) postOnce(body []byte) error {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return permanentError{fmt.Errorf("%s", resp.Status)}
+}